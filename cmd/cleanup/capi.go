@@ -0,0 +1,233 @@
+package cleanup
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/giantswarm/backoff"
+	"github.com/giantswarm/k8sclient/v4/pkg/k8sclient"
+	"github.com/giantswarm/microerror"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/restmapper"
+
+	"github.com/giantswarm/standup/pkg/deletion"
+	"github.com/giantswarm/standup/pkg/key"
+	"github.com/giantswarm/standup/pkg/tracing"
+)
+
+var (
+	appResource       = schema.GroupVersionResource{Group: "application.giantswarm.io", Version: "v1alpha1", Resource: "apps"}
+	capiClusterKind   = schema.GroupKind{Group: "cluster.x-k8s.io", Kind: "Cluster"}
+	configMapResource = schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+	secretResource    = schema.GroupVersionResource{Version: "v1", Resource: "secrets"}
+)
+
+// deleteCAPICluster deletes a CAPI cluster through the management cluster,
+// as the GS API does not manage them. Clusters created from a cluster App are
+// deleted through the App, others through their Cluster CR. It waits for the
+// Cluster, Machines and infrastructure CRs to be gone, then deletes the
+// remaining Apps, ConfigMaps and Secrets of the cluster in the organization
+// namespace. The organization namespace is returned, if the cluster exists.
+func (r *runner) deleteCAPICluster(ctx context.Context, k8sClient k8sclient.Interface, diagnoser *deletion.Diagnoser) (string, error) {
+	mapper := restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(k8sClient.K8sClient().Discovery()))
+	mapping, err := mapper.RESTMapping(capiClusterKind)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+	clusterResource := mapping.Resource
+
+	cluster, err := r.findCAPICluster(ctx, k8sClient, clusterResource)
+	if IsNotFound(err) {
+		r.logger.LogCtx(ctx, "message", "cluster does not exist")
+		return "", nil
+	} else if err != nil {
+		return "", microerror.Mask(err)
+	}
+	namespace := cluster.GetNamespace()
+
+	// The infrastructure CR is not necessarily labelled with the cluster name.
+	var infrastructure *v1.PartialObjectMetadata
+	{
+		ref, found, _ := unstructured.NestedMap(cluster.Object, "spec", "infrastructureRef")
+		if found {
+			apiVersion, _ := ref["apiVersion"].(string)
+			kind, _ := ref["kind"].(string)
+			name, _ := ref["name"].(string)
+			infrastructure = &v1.PartialObjectMetadata{
+				TypeMeta:   v1.TypeMeta{APIVersion: apiVersion, Kind: kind},
+				ObjectMeta: v1.ObjectMeta{Namespace: namespace, Name: name},
+			}
+		}
+	}
+
+	err = r.deleteCAPIClusterObject(ctx, k8sClient, clusterResource, namespace)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	w := r.newWatchdog(diagnoser, fmt.Sprintf("cluster %#q", r.flag.ClusterID), func(ctx context.Context) (deletion.Diagnosis, error) {
+		diagnosis, err := diagnoser.Object(ctx, clusterResource, namespace, r.flag.ClusterID)
+		if deletion.IsNotFound(err) {
+			// The Cluster CR is gone, but some of its objects are not.
+			diagnosis = deletion.Diagnosis{Object: deletion.Object{Kind: "Cluster", Namespace: namespace, Name: r.flag.ClusterID}}
+		} else if err != nil {
+			return deletion.Diagnosis{}, microerror.Mask(err)
+		}
+		diagnosis.Remaining, err = diagnoser.Leftovers(ctx, fmt.Sprintf("%s=%s", key.LabelCAPICluster, r.flag.ClusterID))
+		if err != nil {
+			return deletion.Diagnosis{}, microerror.Mask(err)
+		}
+		return diagnosis, nil
+	})
+
+	// Wait for the Cluster, Machines and infrastructure CRs to be deleted
+	o := func() error {
+		_, err := k8sClient.DynClient().Resource(clusterResource).Namespace(namespace).Get(ctx, r.flag.ClusterID, v1.GetOptions{})
+		if err == nil {
+			r.logger.LogCtx(ctx, "message", "waiting for cluster deletion")
+			w.check(ctx)
+			return microerror.Mask(notYetDeletedError)
+		} else if !apierrors.IsNotFound(err) {
+			return backoff.Permanent(err)
+		}
+
+		if infrastructure != nil {
+			exists, err := objectExists(ctx, k8sClient, mapper, infrastructure)
+			if err != nil {
+				return backoff.Permanent(err)
+			}
+			if exists {
+				r.logger.LogCtx(ctx, "message", fmt.Sprintf("waiting for %s deletion", infrastructure.Kind))
+				w.check(ctx)
+				return microerror.Mask(notYetDeletedError)
+			}
+		}
+
+		leftovers, err := diagnoser.Leftovers(ctx, fmt.Sprintf("%s=%s", key.LabelCAPICluster, r.flag.ClusterID))
+		if err != nil {
+			return backoff.Permanent(err)
+		}
+		if len(leftovers) > 0 {
+			r.logger.LogCtx(ctx, "message", fmt.Sprintf("waiting for deletion of %s", deletion.Summarize(leftovers)))
+			w.check(ctx)
+			return microerror.Mask(notYetDeletedError)
+		}
+
+		return nil
+	}
+	// Retry basically forever, the tekton task will determine maximum runtime.
+	b := key.WaitBackOff(ctx, 0, 20*time.Second)
+
+	err = backoff.RetryNotify(o, b, tracing.Notify(ctx))
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	err = r.deleteCAPIClusterConfig(ctx, k8sClient, namespace)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	return namespace, nil
+}
+
+// findCAPICluster returns the Cluster CR of the cluster in any namespace. It
+// returns notFoundError if there is none.
+func (r *runner) findCAPICluster(ctx context.Context, k8sClient k8sclient.Interface, clusterResource schema.GroupVersionResource) (*unstructured.Unstructured, error) {
+	clusters, err := k8sClient.DynClient().Resource(clusterResource).List(ctx, v1.ListOptions{
+		FieldSelector: fmt.Sprintf("metadata.name=%s", r.flag.ClusterID),
+	})
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	for _, c := range clusters.Items {
+		if c.GetName() == r.flag.ClusterID {
+			return &c, nil
+		}
+	}
+
+	return nil, microerror.Maskf(notFoundError, "cluster %#q", r.flag.ClusterID)
+}
+
+// deleteCAPIClusterObject deletes the cluster App of the cluster, or its
+// Cluster CR if it was not created from an App.
+func (r *runner) deleteCAPIClusterObject(ctx context.Context, k8sClient k8sclient.Interface, clusterResource schema.GroupVersionResource, namespace string) error {
+	_, err := k8sClient.DynClient().Resource(appResource).Namespace(namespace).Get(ctx, r.flag.ClusterID, v1.GetOptions{})
+	if apierrors.IsNotFound(err) || meta.IsNoMatchError(err) {
+		r.logger.LogCtx(ctx, "message", fmt.Sprintf("deleting cluster CR %s/%s", namespace, r.flag.ClusterID))
+		err = k8sClient.DynClient().Resource(clusterResource).Namespace(namespace).Delete(ctx, r.flag.ClusterID, v1.DeleteOptions{})
+		if apierrors.IsNotFound(err) {
+			return nil
+		} else if err != nil {
+			return microerror.Mask(err)
+		}
+
+		return nil
+	} else if err != nil {
+		return microerror.Mask(err)
+	}
+
+	r.logger.LogCtx(ctx, "message", fmt.Sprintf("deleting cluster app %s/%s", namespace, r.flag.ClusterID))
+	err = k8sClient.DynClient().Resource(appResource).Namespace(namespace).Delete(ctx, r.flag.ClusterID, v1.DeleteOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// deleteCAPIClusterConfig deletes the Apps, ConfigMaps and Secrets labelled
+// with the cluster ID in the organization namespace, e.g. the default apps
+// of the cluster and their values.
+func (r *runner) deleteCAPIClusterConfig(ctx context.Context, k8sClient k8sclient.Interface, namespace string) error {
+	selector := fmt.Sprintf("%s=%s", key.LabelCluster, r.flag.ClusterID)
+
+	for _, resource := range []schema.GroupVersionResource{appResource, configMapResource, secretResource} {
+		list, err := k8sClient.DynClient().Resource(resource).Namespace(namespace).List(ctx, v1.ListOptions{
+			LabelSelector: selector,
+		})
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		for _, item := range list.Items {
+			r.logger.LogCtx(ctx, "message", fmt.Sprintf("deleting %s %s/%s", resource.Resource, namespace, item.GetName()))
+			err = k8sClient.DynClient().Resource(resource).Namespace(namespace).Delete(ctx, item.GetName(), v1.DeleteOptions{})
+			if apierrors.IsNotFound(err) {
+				continue
+			} else if err != nil {
+				return microerror.Mask(err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func objectExists(ctx context.Context, k8sClient k8sclient.Interface, mapper meta.RESTMapper, object *v1.PartialObjectMetadata) (bool, error) {
+	gv, err := schema.ParseGroupVersion(object.APIVersion)
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+	mapping, err := mapper.RESTMapping(gv.WithKind(object.Kind).GroupKind(), gv.Version)
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	_, err = k8sClient.DynClient().Resource(mapping.Resource).Namespace(object.Namespace).Get(ctx, object.Name, v1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	} else if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	return true, nil
+}
@@ -28,3 +28,21 @@ var notYetDeletedError = &microerror.Error{
 func IsNotYetDeleted(err error) bool {
 	return microerror.Cause(err) == notYetDeletedError
 }
+
+var leftoversError = &microerror.Error{
+	Kind: "leftoversError",
+}
+
+// IsLeftovers asserts leftoversError.
+func IsLeftovers(err error) bool {
+	return microerror.Cause(err) == leftoversError
+}
+
+var notFoundError = &microerror.Error{
+	Kind: "notFoundError",
+}
+
+// IsNotFound asserts notFoundError.
+func IsNotFound(err error) bool {
+	return microerror.Cause(err) == notFoundError
+}
@@ -1,32 +1,68 @@
 package cleanup
 
 import (
+	"fmt"
+	"strings"
+	"time"
+
 	"github.com/giantswarm/microerror"
 	"github.com/spf13/cobra"
+
+	"github.com/giantswarm/standup/pkg/deletion"
+	"github.com/giantswarm/standup/pkg/key"
+	"github.com/giantswarm/standup/pkg/kubeconfig"
+	"github.com/giantswarm/standup/pkg/metrics"
+	"github.com/giantswarm/standup/pkg/tracing"
 )
 
 const (
-	flagConfig       = "config"
-	flagClusterID    = "cluster"
-	flagKubeconfig   = "kubeconfig"
-	flagInstallation = "installation"
-	flagReleaseID    = "release"
+	flagAllowNonTestRelease = "allow-non-test-release"
+	flagConfig              = "config"
+	flagClusterID           = "cluster"
+	flagCredentials         = "credentials"
+	flagForceFinalizers     = "force-finalizers"
+	flagInstallation        = "installation"
+	flagOutputDir           = "output-dir"
+	flagProvider            = "provider"
+	flagReleaseID           = "release"
+	flagStrict              = "strict"
+	flagStuckThreshold      = "stuck-threshold"
 )
 
 type flag struct {
-	ClusterID    string
-	Config       string
-	Kubeconfig   string
-	Installation string
-	ReleaseID    string
+	AllowNonTestRelease bool
+	ClusterID           string
+	Config              string
+	Credentials         string
+	ForceFinalizers     bool
+	Installation        string
+	OutputDir           string
+	Provider            string
+	ReleaseID           string
+	Strict              bool
+	StuckThreshold      time.Duration
+
+	Kubeconfig kubeconfig.Flags
+	Metrics    metrics.Flags
+	Tracing    tracing.Flags
 }
 
 func (f *flag) Init(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&f.AllowNonTestRelease, flagAllowNonTestRelease, false, fmt.Sprintf(`Delete the release of the cluster also when it is not labelled %s=true. Releases still used by other clusters are never deleted.`, key.LabelTesting))
 	cmd.Flags().StringVarP(&f.ClusterID, flagClusterID, "c", "", `The ID of the cluster to delete.`)
 	cmd.Flags().StringVarP(&f.Config, flagConfig, "g", "", `The path to the file containing API endpoints and tokens for each provider.`)
-	cmd.Flags().StringVarP(&f.Kubeconfig, flagKubeconfig, "k", "", `The path to the directory containing the kubeconfigs for provider control planes.`)
+	cmd.Flags().StringVar(&f.Credentials, flagCredentials, "", `The credentials create cluster wrote to the kubeconfig of the cluster ('certificate' or 'service-account'). Service account tokens are revoked before the cluster is deleted, certificates expire. Defaults to the credentials in --output-dir, or 'certificate'.`)
+	cmd.Flags().BoolVar(&f.ForceFinalizers, flagForceFinalizers, false, fmt.Sprintf(`Strip known-safe finalizers of Giant Swarm operators from objects whose deletion made no progress within --stuck-threshold. Only %s are stripped.`, strings.Join(deletion.SafeFinalizers, ", ")))
 	cmd.Flags().StringVarP(&f.Installation, flagInstallation, "i", "", `The target management cluster type to be used ('aws', 'azure', 'kvm', 'gcp', 'openstack' or 'aws-china').`)
-	cmd.Flags().StringVarP(&f.ReleaseID, flagReleaseID, "r", "", `The release to delete. Defaults to the release of the passed cluster.`)
+	cmd.Flags().StringVar(&f.OutputDir, flagOutputDir, "", `The output directory of the create commands, from which the trace context and credentials are read, and the release and organization once the cluster is gone.`)
+	cmd.Flags().StringVar(&f.Provider, flagProvider, "", `The provider of the installation, selecting its cleanup hooks and labelling metrics. Defaults to the installation for cleanup hooks.`)
+	cmd.Flags().StringVarP(&f.ReleaseID, flagReleaseID, "r", "", `The release to delete. Defaults to the release of the passed cluster, or once it is gone, the release in --output-dir or on leftover objects of the cluster.`)
+	cmd.Flags().BoolVar(&f.Strict, flagStrict, false, `Fail if custom resources labelled with the cluster ID are left in the management cluster after the teardown.`)
+	cmd.Flags().DurationVar(&f.StuckThreshold, flagStuckThreshold, 10*time.Minute, `The time after which a deletion without progress is diagnosed, listing remaining finalizers, conditions and objects. 0 disables the diagnosis.`)
+
+	f.Kubeconfig.Init(cmd)
+	f.Metrics.Init(cmd)
+	f.Tracing.Init(cmd)
 }
 
 func (f *flag) Validate() error {
@@ -38,13 +74,21 @@ func (f *flag) Validate() error {
 		return microerror.Maskf(invalidFlagError, "--%s is required", flagConfig)
 	}
 
-	if f.Kubeconfig == "" {
-		return microerror.Maskf(invalidFlagError, "--%s is required", flagKubeconfig)
+	if f.Credentials != "" && f.Credentials != key.CredentialsCertificate && f.Credentials != key.CredentialsServiceAccount {
+		return microerror.Maskf(invalidFlagError, "--%s must be one of %#q or %#q", flagCredentials, key.CredentialsCertificate, key.CredentialsServiceAccount)
 	}
 
 	if f.Installation == "" {
 		return microerror.Maskf(invalidFlagError, "--%s is required", flagInstallation)
 	}
 
+	if f.StuckThreshold < 0 {
+		return microerror.Maskf(invalidFlagError, "--%s must not be negative", flagStuckThreshold)
+	}
+
+	if f.ForceFinalizers && f.StuckThreshold == 0 {
+		return microerror.Maskf(invalidFlagError, "--%s requires --%s", flagForceFinalizers, flagStuckThreshold)
+	}
+
 	return nil
 }
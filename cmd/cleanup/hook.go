@@ -0,0 +1,68 @@
+package cleanup
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/giantswarm/backoff"
+	"github.com/giantswarm/k8sclient/v4/pkg/k8sclient"
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/standup/pkg/cleanuphook"
+	"github.com/giantswarm/standup/pkg/deletion"
+	"github.com/giantswarm/standup/pkg/key"
+	"github.com/giantswarm/standup/pkg/metrics"
+	"github.com/giantswarm/standup/pkg/tracing"
+)
+
+// runHook waits for the objects of the cluster the given hook selects to be
+// deleted. It returns whether there were any objects to wait for.
+func (r *runner) runHook(ctx context.Context, k8sClient k8sclient.Interface, diagnoser *deletion.Diagnoser, hook cleanuphook.Hook) (bool, error) {
+	r.logger.LogCtx(ctx, "message", fmt.Sprintf("running cleanup hook %#q", hook.Name))
+
+	var remaining []deletion.Object
+	var waited bool
+	w := r.newWatchdog(diagnoser, fmt.Sprintf("objects of cleanup hook %#q", hook.Name), func(ctx context.Context) (deletion.Diagnosis, error) {
+		if len(remaining) == 0 {
+			return deletion.Diagnosis{}, microerror.Maskf(notFoundError, "objects of cleanup hook %#q", hook.Name)
+		}
+		first := remaining[0]
+		diagnosis, err := diagnoser.Object(ctx, first.Resource, first.Namespace, first.Name)
+		if err != nil {
+			return deletion.Diagnosis{}, microerror.Mask(err)
+		}
+		diagnosis.Remaining = remaining[1:]
+		return diagnosis, nil
+	})
+
+	o := func() error {
+		var err error
+		remaining, err = hook.Remaining(ctx, k8sClient.DynClient(), r.flag.ClusterID)
+		if err != nil {
+			return backoff.Permanent(err)
+		}
+		if len(remaining) > 0 {
+			waited = true
+			r.logger.LogCtx(ctx, "message", fmt.Sprintf("waiting for deletion of %s", deletion.Summarize(remaining)))
+			w.check(ctx)
+			return microerror.Mask(notYetDeletedError)
+		}
+		return nil
+	}
+	// Retry basically forever, the tekton task will determine maximum runtime.
+	b := key.WaitBackOff(ctx, 0, 20*time.Second)
+
+	stepCtx, end := tracing.Start(ctx, metrics.StepCleanupHook)
+	done := r.metrics.Step(metrics.StepCleanupHook)
+	err := backoff.RetryNotify(o, b, tracing.Notify(stepCtx))
+	done(err)
+	end(err)
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	r.logger.LogCtx(ctx, "message", fmt.Sprintf("cleanup hook %#q completed", hook.Name))
+
+	return waited, nil
+}
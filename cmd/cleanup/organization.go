@@ -0,0 +1,64 @@
+package cleanup
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/giantswarm/backoff"
+	"github.com/giantswarm/k8sclient/v4/pkg/k8sclient"
+	"github.com/giantswarm/microerror"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/giantswarm/standup/pkg/key"
+)
+
+// deleteEphemeralOrganization deletes the given organization if `create
+// cluster` created it for this cluster only. Shared organizations are kept.
+// It returns the outcome for the report.
+func (r *runner) deleteEphemeralOrganization(ctx context.Context, k8sClient k8sclient.Interface, name string) (string, error) {
+	organization, err := k8sClient.G8sClient().SecurityV1alpha1().Organizations().Get(ctx, name, v1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return outcomeAbsent, nil
+	} else if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	if organization.Labels[key.LabelEphemeralOrganization] != "true" {
+		r.logger.LogCtx(ctx, "message", fmt.Sprintf("keeping organization %#q as it is not ephemeral", name))
+		return outcomeKept, nil
+	}
+
+	r.logger.LogCtx(ctx, "message", fmt.Sprintf("deleting ephemeral organization %#q", name))
+	{
+		err = k8sClient.G8sClient().SecurityV1alpha1().Organizations().Delete(ctx, name, v1.DeleteOptions{})
+		if apierrors.IsNotFound(err) {
+			// fall through
+		} else if err != nil {
+			return "", microerror.Mask(err)
+		}
+
+		// Wait for the organization to be deleted
+		o := func() error {
+			_, err := k8sClient.G8sClient().SecurityV1alpha1().Organizations().Get(ctx, name, v1.GetOptions{})
+			if apierrors.IsNotFound(err) {
+				return nil
+			} else if err != nil {
+				return backoff.Permanent(err)
+			}
+			r.logger.LogCtx(ctx, "message", "waiting for organization deletion")
+			return microerror.Mask(notYetDeletedError)
+		}
+		// Retry basically forever, the tekton task will determine maximum runtime.
+		b := key.WaitBackOff(ctx, 0, 20*time.Second)
+
+		err = backoff.Retry(o, b)
+		if err != nil {
+			return "", microerror.Mask(err)
+		}
+	}
+	r.logger.LogCtx(ctx, "message", fmt.Sprintf("deleted ephemeral organization %#q", name))
+
+	return outcomeDeleted, nil
+}
@@ -0,0 +1,183 @@
+package cleanup
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/giantswarm/apiextensions/v2/pkg/apis/release/v1alpha1"
+	"github.com/giantswarm/backoff"
+	"github.com/giantswarm/k8sclient/v4/pkg/k8sclient"
+	"github.com/giantswarm/microerror"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/giantswarm/standup/pkg/deletion"
+	"github.com/giantswarm/standup/pkg/gsclient"
+	"github.com/giantswarm/standup/pkg/key"
+	"github.com/giantswarm/standup/pkg/tracing"
+)
+
+// deleteRelease removes the reference of the deleted cluster from the given
+// Release CR, then deletes it and waits for it to be gone, unless keepRelease
+// refuses to. It returns the outcome for the report and why the release was
+// kept.
+func (r *runner) deleteRelease(ctx context.Context, k8sClient k8sclient.Interface, gsClient *gsclient.Client, diagnoser *deletion.Diagnoser, releaseVersion string) (string, string, error) {
+	release, err := k8sClient.G8sClient().ReleaseV1alpha1().Releases().Get(ctx, releaseVersion, v1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		r.logger.LogCtx(ctx, "message", "release CR does not exist")
+		return outcomeAbsent, "", nil
+	} else if err != nil {
+		return "", "", microerror.Mask(err)
+	}
+
+	if _, ok := release.Annotations[key.ReleaseClusterAnnotation(r.flag.ClusterID)]; ok {
+		release, err = r.dereferenceRelease(ctx, k8sClient, releaseVersion)
+		if err != nil {
+			return "", "", microerror.Mask(err)
+		}
+		if release == nil {
+			r.logger.LogCtx(ctx, "message", "release CR does not exist")
+			return outcomeAbsent, "", nil
+		}
+	}
+
+	reason, err := r.keepRelease(ctx, gsClient, release)
+	if err != nil {
+		return "", "", microerror.Mask(err)
+	}
+	if reason != "" {
+		r.logger.LogCtx(ctx, "level", "warning", "message", fmt.Sprintf("keeping release CR %s: %s", releaseVersion, reason))
+		return outcomeKept, reason, nil
+	}
+
+	backgroundDeletion := v1.DeletionPropagation("Background")
+	err = k8sClient.G8sClient().ReleaseV1alpha1().Releases().Delete(ctx, releaseVersion, v1.DeleteOptions{
+		PropagationPolicy: &backgroundDeletion,
+	})
+	if apierrors.IsNotFound(err) {
+		r.logger.LogCtx(ctx, "message", "release CR does not exist")
+		return outcomeAbsent, "", nil
+	} else if err != nil {
+		return "", "", microerror.Mask(err)
+	}
+
+	w := r.newWatchdog(diagnoser, fmt.Sprintf("release %#q", releaseVersion), func(ctx context.Context) (deletion.Diagnosis, error) {
+		return diagnoser.Object(ctx, releaseResource, "", releaseVersion)
+	})
+
+	// Wait for the release to be deleted
+	o := func() error {
+		_, err := k8sClient.G8sClient().ReleaseV1alpha1().Releases().Get(ctx, releaseVersion, v1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return nil
+		} else if err != nil {
+			return backoff.Permanent(err)
+		}
+		r.logger.LogCtx(ctx, "message", "waiting for release deletion")
+		w.check(ctx)
+		return microerror.Mask(notYetDeletedError)
+	}
+	// Retry basically forever, the tekton task will determine maximum runtime.
+	b := key.WaitBackOff(ctx, 0, 20*time.Second)
+
+	err = backoff.RetryNotify(o, b, tracing.Notify(ctx))
+	if err != nil {
+		return "", "", microerror.Mask(err)
+	}
+	r.logger.LogCtx(ctx, "message", "deleted release CR")
+
+	return outcomeDeleted, "", nil
+}
+
+// dereferenceRelease removes the annotation referencing the deleted cluster
+// from the given Release CR and returns the patched release, or nil if the
+// release is gone.
+func (r *runner) dereferenceRelease(ctx context.Context, k8sClient k8sclient.Interface, releaseVersion string) (*v1alpha1.Release, error) {
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]interface{}{
+				key.ReleaseClusterAnnotation(r.flag.ClusterID): nil,
+			},
+		},
+	})
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	release, err := k8sClient.G8sClient().ReleaseV1alpha1().Releases().Patch(ctx, releaseVersion, types.MergePatchType, patch, v1.PatchOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	r.logger.LogCtx(ctx, "message", fmt.Sprintf("removed reference of cluster %s from release CR %s", r.flag.ClusterID, releaseVersion))
+
+	return release, nil
+}
+
+// keepRelease returns why the given Release CR must not be deleted, or an
+// empty string if it may be. Only test releases are deleted, unless
+// --allow-non-test-release is set, and never while other clusters, including
+// deleting ones, still use or reference them. References of clusters which
+// are gone are ignored, so a crashed pipeline does not keep the release
+// forever.
+func (r *runner) keepRelease(ctx context.Context, gsClient *gsclient.Client, release *v1alpha1.Release) (string, error) {
+	if release.Labels[key.LabelTesting] != "true" && !r.flag.AllowNonTestRelease {
+		return fmt.Sprintf("not labelled %s=true, pass --%s to delete it", key.LabelTesting, flagAllowNonTestRelease), nil
+	}
+
+	clusters, err := gsClient.ListClusters(ctx)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+	users := clustersUsingRelease(clusters, release.Name, r.flag.ClusterID)
+	if len(users) > 0 {
+		return fmt.Sprintf("still used by clusters %v", users), nil
+	}
+	referrers := clustersReferencingRelease(clusters, release, r.flag.ClusterID)
+	if len(referrers) > 0 {
+		return fmt.Sprintf("still referenced by clusters %v", referrers), nil
+	}
+
+	return "", nil
+}
+
+// clustersUsingRelease returns the IDs of the clusters on the given release,
+// except for the given cluster.
+func clustersUsingRelease(clusters []gsclient.ClusterEntry, releaseVersion, except string) []string {
+	var ids []string
+	for _, c := range clusters {
+		if c.ID == except {
+			continue
+		}
+		// The GS API omits the leading v of release names.
+		if strings.TrimPrefix(c.ReleaseVersion, "v") == strings.TrimPrefix(releaseVersion, "v") {
+			ids = append(ids, c.ID)
+		}
+	}
+
+	return ids
+}
+
+// clustersReferencingRelease returns the IDs of the existing clusters
+// referenced by the annotations of the given Release CR, except for the given
+// cluster. Clusters upgraded away from the release still reference it.
+func clustersReferencingRelease(clusters []gsclient.ClusterEntry, release *v1alpha1.Release, except string) []string {
+	existing := map[string]bool{}
+	for _, c := range clusters {
+		existing[c.ID] = true
+	}
+
+	var ids []string
+	for _, id := range key.ReleaseClusters(release.Annotations) {
+		if id != except && existing[id] {
+			ids = append(ids, id)
+		}
+	}
+
+	return ids
+}
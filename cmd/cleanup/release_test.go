@@ -0,0 +1,112 @@
+package cleanup
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/giantswarm/apiextensions/v2/pkg/apis/release/v1alpha1"
+	"github.com/google/go-cmp/cmp"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/giantswarm/standup/pkg/gsclient"
+)
+
+func Test_clustersUsingRelease(t *testing.T) {
+	clusters := []gsclient.ClusterEntry{
+		{ID: "abc12", ReleaseVersion: "13.0.0-1"},
+		{ID: "def34", ReleaseVersion: "13.0.0-1"},
+		{ID: "ghi56", ReleaseVersion: "13.0.0"},
+	}
+
+	testCases := []struct {
+		name           string
+		releaseVersion string
+		except         string
+		expected       []string
+	}{
+		{
+			name:           "case 0: release used by another cluster",
+			releaseVersion: "v13.0.0-1",
+			except:         "abc12",
+			expected:       []string{"def34"},
+		},
+		{
+			name:           "case 1: release used by the deleted cluster only",
+			releaseVersion: "v13.0.0",
+			except:         "ghi56",
+			expected:       nil,
+		},
+		{
+			name:           "case 2: unused release",
+			releaseVersion: "v14.0.0",
+			except:         "abc12",
+			expected:       nil,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			result := clustersUsingRelease(clusters, tc.releaseVersion, tc.except)
+
+			if !cmp.Equal(result, tc.expected) {
+				t.Fatalf("\n\n%s\n", cmp.Diff(tc.expected, result))
+			}
+		})
+	}
+}
+
+func Test_clustersReferencingRelease(t *testing.T) {
+	clusters := []gsclient.ClusterEntry{
+		{ID: "abc12", ReleaseVersion: "13.0.0-1"},
+		{ID: "def34", ReleaseVersion: "13.0.1"},
+	}
+
+	testCases := []struct {
+		name        string
+		annotations map[string]string
+		except      string
+		expected    []string
+	}{
+		{
+			name: "case 0: release referenced by another cluster upgraded away from it",
+			annotations: map[string]string{
+				"clusters.standup.giantswarm.io/abc12": "true",
+				"clusters.standup.giantswarm.io/def34": "true",
+			},
+			except:   "abc12",
+			expected: []string{"def34"},
+		},
+		{
+			name: "case 1: release referenced by the deleted cluster only",
+			annotations: map[string]string{
+				"clusters.standup.giantswarm.io/abc12": "true",
+			},
+			except:   "abc12",
+			expected: nil,
+		},
+		{
+			name: "case 2: references of clusters which are gone are ignored",
+			annotations: map[string]string{
+				"clusters.standup.giantswarm.io/ghi56": "true",
+				"giantswarm.io/notes":                  "ghi56",
+			},
+			except:   "abc12",
+			expected: nil,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			release := &v1alpha1.Release{ObjectMeta: v1.ObjectMeta{Annotations: tc.annotations}}
+			result := clustersReferencingRelease(clusters, release, tc.except)
+
+			if !cmp.Equal(result, tc.expected) {
+				t.Fatalf("\n\n%s\n", cmp.Diff(tc.expected, result))
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package cleanup
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/giantswarm/microerror"
+)
+
+// Outcomes of the teardown of a resource.
+const (
+	outcomeAbsent   = "already absent"
+	outcomeDeleted  = "deleted"
+	outcomeFailed   = "failed"
+	outcomeKept     = "kept"
+	outcomeReleased = "released"
+	outcomeSkipped  = "skipped"
+)
+
+// Resources of the report.
+const (
+	resourceCapacitySlot = "capacity slot"
+	resourceCluster      = "cluster"
+	resourceCredentials  = "credentials"
+	resourceHook         = "cleanup hook"
+	resourceLeftovers    = "leftovers"
+	resourceNamespace    = "namespace"
+	resourceOrganization = "organization"
+	resourceRelease      = "release"
+)
+
+// report records the outcome of the teardown of each resource, so a re-run
+// after a partial failure shows what was left to do.
+type report struct {
+	entries []reportEntry
+}
+
+type reportEntry struct {
+	Resource string
+	Name     string
+	Outcome  string
+	Message  string
+}
+
+func (r *report) add(resource, name, outcome, message string) {
+	r.entries = append(r.entries, reportEntry{
+		Resource: resource,
+		Name:     name,
+		Outcome:  outcome,
+		Message:  message,
+	})
+}
+
+func (r *report) fail(resource, name string, err error) {
+	r.add(resource, name, outcomeFailed, microerror.Pretty(err, false))
+}
+
+func (r *report) write(w io.Writer) error {
+	if len(r.entries) == 0 {
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "RESOURCE\tNAME\tOUTCOME\tMESSAGE\n")
+	for _, e := range r.entries {
+		name := e.Name
+		if name == "" {
+			name = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", e.Resource, name, e.Outcome, e.Message)
+	}
+
+	err := tw.Flush()
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
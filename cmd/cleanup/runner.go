@@ -4,6 +4,9 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/giantswarm/backoff"
@@ -13,33 +16,95 @@ import (
 	"github.com/spf13/cobra"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
-	"k8s.io/client-go/tools/clientcmd"
 
+	"github.com/giantswarm/standup/pkg/cleanuphook"
 	"github.com/giantswarm/standup/pkg/config"
+	"github.com/giantswarm/standup/pkg/credentials"
+	"github.com/giantswarm/standup/pkg/deletion"
 	"github.com/giantswarm/standup/pkg/gsclient"
 	"github.com/giantswarm/standup/pkg/key"
+	"github.com/giantswarm/standup/pkg/metrics"
+	"github.com/giantswarm/standup/pkg/semaphore"
+	"github.com/giantswarm/standup/pkg/tracing"
+)
+
+var (
+	releaseResource = schema.GroupVersionResource{Group: "release.giantswarm.io", Version: "v1alpha1", Resource: "releases"}
 )
 
 type runner struct {
-	flag   *flag
-	logger micrologger.Logger
-	stdout io.Writer
-	stderr io.Writer
+	flag    *flag
+	logger  micrologger.Logger
+	metrics *metrics.Recorder
+	report  *report
+	tracing *tracing.Tracing
+	stdout  io.Writer
+	stderr  io.Writer
 }
 
 func (r *runner) Run(cmd *cobra.Command, args []string) error {
-	ctx := context.Background()
+	ctx := cmd.Context()
 
 	err := r.flag.Validate()
 	if err != nil {
 		return microerror.Mask(err)
 	}
 
+	r.metrics, err = metrics.New(metrics.Config{
+		Logger: r.logger,
+
+		Command:     cmd.Name(),
+		File:        r.flag.Metrics.File,
+		Pushgateway: r.flag.Metrics.Pushgateway,
+	})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	// Metrics are flushed also when the command fails, without failing it.
+	defer func() {
+		err := r.metrics.Flush(context.Background())
+		if err != nil {
+			r.logger.LogCtx(ctx, "level", "warning", "message", "failed to flush metrics", "stack", microerror.JSON(err))
+		}
+	}()
+
+	traceparent, err := r.flag.Tracing.ResolveTraceparent(r.flag.OutputDir)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	r.tracing, ctx, err = tracing.New(ctx, tracing.Config{
+		Logger: r.logger,
+
+		Command:     cmd.CommandPath(),
+		Endpoint:    r.flag.Tracing.Endpoint,
+		Traceparent: traceparent,
+	})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	// Spans are exported also when the command fails, without failing it.
+	// err holds the result of the command by the time this runs.
+	defer func() {
+		endErr := r.tracing.End(err)
+		if endErr != nil {
+			r.logger.LogCtx(ctx, "level", "warning", "message", "failed to export traces", "stack", microerror.JSON(endErr))
+		}
+	}()
+
+	// The report shows what was torn down also when the command fails.
+	r.report = &report{}
 	err = r.run(ctx, cmd, args)
+	reportErr := r.report.write(r.stdout)
 	if err != nil {
 		return microerror.Mask(err)
 	}
+	if reportErr != nil {
+		return microerror.Mask(reportErr)
+	}
 
 	return nil
 }
@@ -73,15 +138,11 @@ func (r *runner) run(ctx context.Context, _ *cobra.Command, _ []string) error {
 		}
 	}
 
-	kubeconfigPath := key.KubeconfigPath(r.flag.Kubeconfig, r.flag.Installation)
-
 	// Create REST config for the control plane
 	var restConfig *rest.Config
 	{
 		var err error
-		restConfig, err = clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
-			&clientcmd.ClientConfigLoadingRules{ExplicitPath: kubeconfigPath},
-			&clientcmd.ConfigOverrides{}).ClientConfig()
+		restConfig, err = r.flag.Kubeconfig.RESTConfig(r.flag.Installation, providerConfig.Kubeconfig)
 		if err != nil {
 			return microerror.Mask(err)
 		}
@@ -100,32 +161,151 @@ func (r *runner) run(ctx context.Context, _ *cobra.Command, _ []string) error {
 		}
 	}
 
-	// Get release version of tenant cluster
+	var diagnoser *deletion.Diagnoser
+	{
+		c := deletion.Config{
+			DynClient: k8sClient.DynClient(),
+			K8sClient: k8sClient.K8sClient(),
+			Logger:    r.logger,
+		}
+
+		var err error
+		diagnoser, err = deletion.New(c)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	var hooks []cleanuphook.Hook
+	{
+		provider := r.flag.Provider
+		if provider == "" {
+			provider = r.flag.Installation
+		}
+
+		var err error
+		hooks, err = cleanuphook.Select(provider, providerConfig.Cleanup.Hooks, providerConfig.Cleanup.CustomHooks)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	// Get release version and organization of tenant cluster. Once the cluster is gone, they are
+	// recovered from the output directory of the create commands and leftover objects, so a
+	// cleanup can be re-run after a partial failure.
 	var releaseVersion string
+	var organization string
+	var clusterExists bool
 	{
+		cluster, err := gsClient.GetCluster(ctx, r.flag.ClusterID)
+		if gsclient.IsClusterNotFoundError(err) {
+			r.logger.LogCtx(ctx, "message", "cluster does not exist")
+			// fall through
+		} else if err != nil {
+			return microerror.Mask(err)
+		} else {
+			clusterExists = true
+		}
+
+		organization = cluster.Owner
+
 		if r.flag.ReleaseID != "" {
 			releaseVersion = r.flag.ReleaseID
+		} else if clusterExists {
+			// Have to add back the leading v in the release name
+			releaseVersion = fmt.Sprintf("v%s", cluster.ReleaseVersion)
 		} else {
-			var err error
-			releaseVersion, err = gsClient.GetClusterReleaseVersion(ctx, r.flag.ClusterID)
+			releaseVersion, err = r.recoverRelease(ctx, diagnoser)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+		}
+
+		if organization == "" {
+			organization, err = r.fromOutputDir("organization")
 			if err != nil {
 				return microerror.Mask(err)
 			}
 		}
 	}
 
+	r.metrics.SetLabels(metrics.Labels{
+		Installation: r.flag.Installation,
+		Provider:     r.flag.Provider,
+		Release:      strings.TrimPrefix(releaseVersion, "v"),
+	})
+
 	r.logger.LogCtx(ctx, "message", "beginning teardown")
 
+	credentialsType := r.flag.Credentials
+	if credentialsType == "" {
+		var err error
+		credentialsType, err = r.fromOutputDir("credentials")
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	// Revoke the credentials of the tests first, so leaked kubeconfigs are useless even if the
+	// deletion of the cluster fails. Deleting the cluster revokes them anyway, so failures are
+	// not fatal.
+	if credentialsType == key.CredentialsServiceAccount {
+		if !clusterExists {
+			r.report.add(resourceCredentials, r.flag.ClusterID, outcomeAbsent, "revoked with the cluster")
+		} else {
+			err := r.revokeCredentials(ctx, gsClient)
+			if err != nil {
+				r.logger.LogCtx(ctx, "level", "warning", "message", "failed to revoke service account credentials", "stack", microerror.JSON(err))
+				r.report.add(resourceCredentials, r.flag.ClusterID, outcomeFailed, "revoked with the cluster")
+			} else {
+				r.report.add(resourceCredentials, r.flag.ClusterID, outcomeDeleted, "")
+			}
+		}
+	}
+
 	r.logger.LogCtx(ctx, "message", "deleting cluster")
-	{
-		err := gsClient.DeleteCluster(ctx, r.flag.ClusterID)
+	if key.IsCapiRelease(releaseVersion) {
+		stepCtx, end := tracing.Start(ctx, metrics.StepClusterDeletion)
+		done := r.metrics.Step(metrics.StepClusterDeletion)
+		namespace, err := r.deleteCAPICluster(stepCtx, k8sClient, diagnoser)
+		done(err)
+		end(err)
+		if err != nil {
+			r.report.fail(resourceCluster, r.flag.ClusterID, err)
+			return microerror.Mask(err)
+		}
+
+		if namespace == "" {
+			r.report.add(resourceCluster, r.flag.ClusterID, outcomeAbsent, "")
+		} else {
+			r.report.add(resourceCluster, r.flag.ClusterID, outcomeDeleted, "")
+		}
+
+		// CAPI clusters may be unknown to the GS API, but live in the namespace of their organization.
+		if organization == "" && namespace != "" {
+			organization = key.OrganizationOfNamespace(namespace)
+		}
+	} else {
+		stepCtx, end := tracing.Start(ctx, metrics.StepClusterDeletion)
+		done := r.metrics.Step(metrics.StepClusterDeletion)
+		outcome := outcomeDeleted
+		err := gsClient.DeleteCluster(stepCtx, r.flag.ClusterID)
 		if gsclient.IsClusterNotFoundError(err) {
 			r.logger.LogCtx(ctx, "message", "cluster does not exist")
+			outcome = outcomeAbsent
 			// fall through
 		} else if err != nil {
+			done(err)
+			end(err)
+			r.report.fail(resourceCluster, r.flag.ClusterID, err)
 			return microerror.Mask(err)
 		}
 
+		// The objects of the cluster live in its namespace.
+		w := r.newWatchdog(diagnoser, fmt.Sprintf("cluster %#q", r.flag.ClusterID), func(ctx context.Context) (deletion.Diagnosis, error) {
+			return diagnoser.Namespace(ctx, r.flag.ClusterID)
+		})
+
 		// Wait for the cluster to be deleted
 		o := func() error {
 			clusters, err := gsClient.ListClusters(ctx)
@@ -135,86 +315,69 @@ func (r *runner) run(ctx context.Context, _ *cobra.Command, _ []string) error {
 			for _, cluster := range clusters {
 				if cluster.ID == r.flag.ClusterID {
 					r.logger.LogCtx(ctx, "message", "waiting for cluster deletion")
+					w.check(ctx)
 					return microerror.Mask(notYetDeletedError)
 				}
 			}
 			return nil
 		}
 		// Retry basically forever, the tekton task will determine maximum runtime.
-		b := backoff.NewMaxRetries(^uint64(0), 20*time.Second)
+		b := key.WaitBackOff(ctx, 0, 20*time.Second)
 
-		err = backoff.Retry(o, b)
+		err = backoff.RetryNotify(o, b, tracing.Notify(stepCtx))
+		done(err)
+		end(err)
 		if err != nil {
+			r.report.fail(resourceCluster, r.flag.ClusterID, err)
 			return microerror.Mask(err)
 		}
+		r.report.add(resourceCluster, r.flag.ClusterID, outcome, "")
 	}
 	r.logger.LogCtx(ctx, "message", "deleted cluster")
 
-	if r.flag.Installation == "kvm" {
-		// KVM specific logic to wait for the KVMConfig to be gone before deleting the release
-		// TODO: This can be reverted once cluster-service returns deleting clusters for KVM
-		r.logger.LogCtx(ctx, "message", "waiting for kvmconfig deletion")
-		{
-			// Wait for the KVMConfig to be deleted
-			o := func() error {
-				_, err := k8sClient.G8sClient().ProviderV1alpha1().KVMConfigs(v1.NamespaceDefault).Get(ctx, r.flag.ClusterID, v1.GetOptions{})
-				if apierrors.IsNotFound(err) {
-					return nil
-				} else if err != nil {
-					return backoff.Permanent(err)
-				}
-				r.logger.LogCtx(ctx, "message", "waiting for kvmconfig deletion")
-				return microerror.Mask(notYetDeletedError)
-			}
-			// Retry basically forever, the tekton task will determine maximum runtime.
-			b := backoff.NewMaxRetries(^uint64(0), 20*time.Second)
-
-			err := backoff.Retry(o, b)
-			if err != nil {
-				return microerror.Mask(err)
-			}
+	// Provider operators may need the release to delete their objects of the cluster.
+	for _, hook := range hooks {
+		deleted, err := r.runHook(ctx, k8sClient, diagnoser, hook)
+		if err != nil {
+			r.report.fail(resourceHook, hook.Name, err)
+			return microerror.Mask(err)
+		}
+		if deleted {
+			r.report.add(resourceHook, hook.Name, outcomeDeleted, "")
+		} else {
+			r.report.add(resourceHook, hook.Name, outcomeAbsent, "")
 		}
-		r.logger.LogCtx(ctx, "message", "kvmconfig has been deleted")
 	}
 
 	// CAPI releases are a special case. We don't create a new release thus we don't want to delete it.
-	if !key.IsCapiRelease(releaseVersion) {
-		// Delete the Release CR
+	if key.IsCapiRelease(releaseVersion) {
+		r.report.add(resourceRelease, releaseVersion, outcomeKept, "CAPI releases are not created by standup")
+	} else if releaseVersion == "" {
+		r.report.add(resourceRelease, "", outcomeSkipped, fmt.Sprintf("release of the cluster is unknown, pass --%s", flagReleaseID))
+	} else {
 		r.logger.LogCtx(ctx, "message", "deleting release CR")
 		{
-			backgroundDeletion := v1.DeletionPropagation("Background")
-			err := k8sClient.G8sClient().ReleaseV1alpha1().Releases().Delete(ctx, releaseVersion, v1.DeleteOptions{
-				PropagationPolicy: &backgroundDeletion,
-			})
-			if err != nil {
-				return microerror.Mask(err)
-			}
-
-			// Wait for the release to be deleted
-			o := func() error {
-				_, err := k8sClient.G8sClient().ReleaseV1alpha1().Releases().Get(ctx, releaseVersion, v1.GetOptions{})
-				if apierrors.IsNotFound(err) {
-					return nil
-				} else if err != nil {
-					return backoff.Permanent(err)
-				}
-				r.logger.LogCtx(ctx, "message", "waiting for release deletion")
-				return microerror.Mask(notYetDeletedError)
-			}
-			// Retry basically forever, the tekton task will determine maximum runtime.
-			b := backoff.NewMaxRetries(^uint64(0), 20*time.Second)
-
-			err = backoff.Retry(o, b)
+			stepCtx, end := tracing.Start(ctx, metrics.StepReleaseDeletion)
+			done := r.metrics.Step(metrics.StepReleaseDeletion)
+			outcome, reason, err := r.deleteRelease(stepCtx, k8sClient, gsClient, diagnoser, releaseVersion)
+			done(err)
+			end(err)
 			if err != nil {
+				r.report.fail(resourceRelease, releaseVersion, err)
 				return microerror.Mask(err)
 			}
+			r.report.add(resourceRelease, releaseVersion, outcome, reason)
 		}
-		r.logger.LogCtx(ctx, "message", "deleted release CR")
 	}
 
 	r.logger.LogCtx(ctx, "message", fmt.Sprintf("waiting for %#q namespace deletion", r.flag.ClusterID))
 	{
+		outcome := outcomeAbsent
 		{
+			w := r.newWatchdog(diagnoser, fmt.Sprintf("namespace %#q", r.flag.ClusterID), func(ctx context.Context) (deletion.Diagnosis, error) {
+				return diagnoser.Namespace(ctx, r.flag.ClusterID)
+			})
+
 			// Wait for the cluster namespace to be deleted
 			o := func() error {
 				_, err := k8sClient.K8sClient().CoreV1().Namespaces().Get(ctx, r.flag.ClusterID, v1.GetOptions{})
@@ -223,20 +386,187 @@ func (r *runner) run(ctx context.Context, _ *cobra.Command, _ []string) error {
 				} else if err != nil {
 					return backoff.Permanent(err)
 				}
+				outcome = outcomeDeleted
+				w.check(ctx)
 				return microerror.Mask(notYetDeletedError)
 			}
 			// Retry basically forever, the tekton task will determine maximum runtime.
-			b := backoff.NewMaxRetries(^uint64(0), 20*time.Second)
+			b := key.WaitBackOff(ctx, 0, 20*time.Second)
 
 			err := backoff.Retry(o, b)
 			if err != nil {
+				r.report.fail(resourceNamespace, r.flag.ClusterID, err)
 				return microerror.Mask(err)
 			}
 		}
+		r.report.add(resourceNamespace, r.flag.ClusterID, outcome, "")
 		r.logger.LogCtx(ctx, "message", fmt.Sprintf("namespace %#q has been deleted", r.flag.ClusterID))
 	}
 
+	// Free the capacity slot held by the cluster if the installation limits the number of test clusters.
+	if providerConfig.Capacity.Slots > 0 {
+		c := semaphore.Config{
+			K8sClient: k8sClient.K8sClient(),
+			Logger:    r.logger,
+
+			Name:            key.CapacityConfigMapName,
+			Namespace:       key.CapacityConfigMapNamespace,
+			Slots:           providerConfig.Capacity.Slots,
+			MaxSlotLifetime: providerConfig.Capacity.MaxSlotLifetime.Duration,
+		}
+
+		capacity, err := semaphore.New(c)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		err = capacity.Release(ctx, r.flag.ClusterID)
+		if err != nil {
+			r.report.fail(resourceCapacitySlot, r.flag.ClusterID, err)
+			return microerror.Mask(err)
+		}
+		r.report.add(resourceCapacitySlot, r.flag.ClusterID, outcomeReleased, "")
+	}
+
+	if organization != "" {
+		stepCtx, end := tracing.Start(ctx, metrics.StepOrganizationDeletion)
+		done := r.metrics.Step(metrics.StepOrganizationDeletion)
+		outcome, err := r.deleteEphemeralOrganization(stepCtx, k8sClient, organization)
+		done(err)
+		end(err)
+		if err != nil {
+			r.report.fail(resourceOrganization, organization, err)
+			return microerror.Mask(err)
+		}
+		r.report.add(resourceOrganization, organization, outcome, "")
+	} else {
+		r.report.add(resourceOrganization, "", outcomeSkipped, "organization of the cluster is unknown")
+	}
+
+	{
+		stepCtx, end := tracing.Start(ctx, metrics.StepVerification)
+		done := r.metrics.Step(metrics.StepVerification)
+		err := r.verify(stepCtx, diagnoser)
+		done(err)
+		end(err)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
 	r.logger.LogCtx(ctx, "message", "teardown complete")
 
 	return nil
 }
+
+// recoverRelease returns the release of a cluster which is gone from the GS
+// API, as written to the output directory by create release, or as labelled
+// on objects of the cluster which are left. It returns an empty version if
+// neither knows the release.
+func (r *runner) recoverRelease(ctx context.Context, diagnoser *deletion.Diagnoser) (string, error) {
+	releaseVersion, err := r.fromOutputDir("release-id")
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+	if releaseVersion != "" {
+		r.logger.LogCtx(ctx, "message", fmt.Sprintf("found release %s in %s", releaseVersion, r.flag.OutputDir))
+		return releaseVersion, nil
+	}
+
+	leftovers, err := diagnoser.Leftovers(ctx, fmt.Sprintf("%s=%s", key.LabelCluster, r.flag.ClusterID))
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+	for _, o := range leftovers {
+		if v := o.Labels[key.LabelReleaseVersion]; v != "" {
+			releaseVersion = "v" + strings.TrimPrefix(v, "v")
+			r.logger.LogCtx(ctx, "message", fmt.Sprintf("found release %s on %s", releaseVersion, o))
+			return releaseVersion, nil
+		}
+	}
+
+	r.logger.LogCtx(ctx, "level", "warning", "message", "unable to determine the release of the cluster")
+
+	return "", nil
+}
+
+// fromOutputDir returns the content of the given file in the output
+// directory, if any.
+func (r *runner) fromOutputDir(file string) (string, error) {
+	if r.flag.OutputDir == "" {
+		return "", nil
+	}
+
+	data, err := os.ReadFile(filepath.Join(r.flag.OutputDir, file))
+	if os.IsNotExist(err) {
+		return "", nil
+	} else if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	return strings.TrimSpace(string(data)), nil
+}
+
+// verify searches the management cluster for custom resources of any CRD
+// labelled with the cluster ID, which provider operators may leave behind
+// once the namespace is gone. Leftovers fail the cleanup with --strict only.
+func (r *runner) verify(ctx context.Context, diagnoser *deletion.Diagnoser) error {
+	r.logger.LogCtx(ctx, "message", "searching for leftovers of the cluster")
+
+	leftovers, err := diagnoser.Leftovers(ctx, fmt.Sprintf("%s=%s", key.LabelCluster, r.flag.ClusterID))
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	if len(leftovers) == 0 {
+		r.logger.LogCtx(ctx, "message", "found no leftovers of the cluster")
+		r.report.add(resourceLeftovers, "", outcomeAbsent, "")
+		return nil
+	}
+
+	summary := deletion.Summarize(leftovers)
+	r.report.add(resourceLeftovers, "", outcomeKept, summary)
+	if r.flag.Strict {
+		return microerror.Maskf(leftoversError, "found %d objects of cluster %#q: %s", len(leftovers), r.flag.ClusterID, summary)
+	}
+	r.logger.LogCtx(ctx, "level", "warning", "message", fmt.Sprintf("found %d objects of cluster %#q: %s", len(leftovers), r.flag.ClusterID, summary))
+
+	return nil
+}
+
+// revokeCredentials deletes the service account whose token create cluster
+// wrote to the kubeconfig of the cluster, using a short-lived admin
+// certificate.
+func (r *runner) revokeCredentials(ctx context.Context, gsClient *gsclient.Client) error {
+	restConfig, err := gsClient.CreateRESTConfig(ctx, r.flag.ClusterID, gsclient.KubeconfigOptions{
+		CertificateOrganizations: []string{key.DefaultCertificateOrganization},
+		TTL:                      time.Hour,
+	})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	k8sClient, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	c := credentials.Config{
+		K8sClient: k8sClient,
+		Logger:    r.logger,
+
+		Server: restConfig.Host,
+		CAData: restConfig.CAData,
+	}
+
+	serviceAccount, err := credentials.New(c)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	err = serviceAccount.Revoke(ctx)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,76 @@
+package cleanup
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+
+	"github.com/giantswarm/standup/pkg/deletion"
+)
+
+// watchdog diagnoses a deletion which did not complete within the stuck
+// threshold, and again whenever it made no progress for another threshold.
+// Progress is any change in the diagnosis.
+type watchdog struct {
+	diagnose  func(ctx context.Context) (deletion.Diagnosis, error)
+	diagnoser *deletion.Diagnoser
+	force     bool
+	logger    micrologger.Logger
+	name      string
+	threshold time.Duration
+
+	last  *deletion.Diagnosis
+	since time.Time
+}
+
+func (r *runner) newWatchdog(diagnoser *deletion.Diagnoser, name string, diagnose func(ctx context.Context) (deletion.Diagnosis, error)) *watchdog {
+	return &watchdog{
+		diagnose:  diagnose,
+		diagnoser: diagnoser,
+		force:     r.flag.ForceFinalizers,
+		logger:    r.logger,
+		name:      name,
+		threshold: r.flag.StuckThreshold,
+
+		since: time.Now(),
+	}
+}
+
+// check is called on every poll of a deletion which is not complete yet.
+// Failing diagnoses are logged but never fail the deletion.
+func (w *watchdog) check(ctx context.Context) {
+	if w.threshold == 0 || time.Since(w.since) < w.threshold {
+		return
+	}
+	stalled := time.Since(w.since).Round(time.Second)
+	w.since = time.Now()
+
+	diagnosis, err := w.diagnose(ctx)
+	if deletion.IsNotFound(err) {
+		return
+	} else if err != nil {
+		w.logger.LogCtx(ctx, "level", "warning", "message", fmt.Sprintf("failed to diagnose deletion of %s", w.name), "stack", microerror.JSON(err))
+		return
+	}
+
+	progressed := w.last != nil && !w.last.Equal(diagnosis)
+	w.last = &diagnosis
+	if progressed {
+		w.logger.LogCtx(ctx, "message", fmt.Sprintf("deletion of %s is progressing: %s", w.name, diagnosis))
+		return
+	}
+
+	w.logger.LogCtx(ctx, "level", "warning", "message", fmt.Sprintf("deletion of %s made no progress in %s: %s", w.name, stalled, diagnosis))
+
+	if w.force {
+		changed, err := w.diagnoser.ForceFinalizers(ctx, diagnosis)
+		if err != nil {
+			w.logger.LogCtx(ctx, "level", "warning", "message", fmt.Sprintf("failed to strip finalizers blocking deletion of %s", w.name), "stack", microerror.JSON(err))
+		} else if len(changed) == 0 {
+			w.logger.LogCtx(ctx, "level", "warning", "message", fmt.Sprintf("no known-safe finalizers block deletion of %s", w.name))
+		}
+	}
+}
@@ -5,13 +5,16 @@ import (
 	"os"
 
 	"github.com/giantswarm/microerror"
-	"github.com/giantswarm/micrologger"
 	"github.com/spf13/cobra"
 
 	"github.com/giantswarm/standup/cmd/cleanup"
 	"github.com/giantswarm/standup/cmd/create"
+	"github.com/giantswarm/standup/cmd/list"
+	"github.com/giantswarm/standup/cmd/release"
+	"github.com/giantswarm/standup/cmd/status"
 	"github.com/giantswarm/standup/cmd/version"
 	"github.com/giantswarm/standup/cmd/wait"
+	"github.com/giantswarm/standup/pkg/logging"
 )
 
 const (
@@ -20,7 +23,7 @@ const (
 )
 
 type Config struct {
-	Logger micrologger.Logger
+	Logger *logging.Logger
 	Stderr io.Writer
 	Stdout io.Writer
 
@@ -77,6 +80,48 @@ func New(config Config) (*cobra.Command, error) {
 		}
 	}
 
+	var listCmd *cobra.Command
+	{
+		c := list.Config{
+			Logger: config.Logger,
+			Stderr: config.Stderr,
+			Stdout: config.Stdout,
+		}
+
+		listCmd, err = list.New(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	var releaseCmd *cobra.Command
+	{
+		c := release.Config{
+			Logger: config.Logger,
+			Stderr: config.Stderr,
+			Stdout: config.Stdout,
+		}
+
+		releaseCmd, err = release.New(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	var statusCmd *cobra.Command
+	{
+		c := status.Config{
+			Logger: config.Logger,
+			Stderr: config.Stderr,
+			Stdout: config.Stdout,
+		}
+
+		statusCmd, err = status.New(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
 	var versionCmd *cobra.Command
 	{
 		c := version.Config{
@@ -118,17 +163,23 @@ func New(config Config) (*cobra.Command, error) {
 	}
 
 	c := &cobra.Command{
-		Use:          name,
-		Short:        description,
-		Long:         description,
-		RunE:         r.Run,
-		SilenceUsage: true,
+		Use:               name,
+		Short:             description,
+		Long:              description,
+		RunE:              r.Run,
+		PersistentPreRunE: r.PersistentPreRun,
+		// Errors are rendered with their exit code and a hint by main.
+		SilenceErrors: true,
+		SilenceUsage:  true,
 	}
 
 	f.Init(c)
 
 	c.AddCommand(cleanupCmd)
 	c.AddCommand(createCmd)
+	c.AddCommand(listCmd)
+	c.AddCommand(releaseCmd)
+	c.AddCommand(statusCmd)
 	c.AddCommand(versionCmd)
 	c.AddCommand(waitCmd)
 
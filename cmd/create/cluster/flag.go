@@ -2,34 +2,84 @@ package cluster
 
 import (
 	"strings"
+	"time"
 
 	"github.com/Masterminds/semver/v3"
 	"github.com/giantswarm/microerror"
 	"github.com/spf13/cobra"
+
+	"github.com/giantswarm/standup/pkg/key"
+	"github.com/giantswarm/standup/pkg/kubeconfig"
+	"github.com/giantswarm/standup/pkg/metrics"
+	"github.com/giantswarm/standup/pkg/pipelinerun"
+	"github.com/giantswarm/standup/pkg/tracing"
 )
 
 const (
-	flagConfig       = "config"
-	flagInstallation = "installation"
-	flagKubeconfig   = "kubeconfig"
-	flagOutput       = "output"
-	flagRelease      = "release"
+	flagCertificateOrganizations  = "certificate-organizations"
+	flagCertificateTTL            = "certificate-ttl"
+	flagConfig                    = "config"
+	flagCredentials               = "credentials"
+	flagEphemeralOrganization     = "ephemeral-organization"
+	flagInstallation              = "installation"
+	flagOrganization              = "organization"
+	flagOrganizationStrategy      = "organization-strategy"
+	flagOutput                    = "output"
+	flagPipeline                  = "pipeline"
+	flagPipelineConfig            = "pipeline-config"
+	flagProvider                  = "provider"
+	flagRelease                   = "release"
+	flagRollbackGracePeriod       = "rollback-grace-period"
+	flagServiceAccountClusterRole = "service-account-cluster-role"
+	flagSpec                      = "spec"
 )
 
 type flag struct {
-	Config       string
-	Kubeconfig   string
-	Installation string
-	Output       string
-	Release      string
+	CertificateOrganizations  []string
+	CertificateTTL            time.Duration
+	Config                    string
+	Credentials               string
+	EphemeralOrganization     bool
+	Installation              string
+	Organization              string
+	OrganizationStrategy      string
+	Output                    string
+	Pipeline                  string
+	PipelineConfig            string
+	Provider                  string
+	Release                   string
+	RollbackGracePeriod       time.Duration
+	ServiceAccountClusterRole string
+	Spec                      string
+
+	Kubeconfig kubeconfig.Flags
+	Metadata   pipelinerun.Metadata
+	Metrics    metrics.Flags
+	Tracing    tracing.Flags
 }
 
 func (f *flag) Init(cmd *cobra.Command) {
 	cmd.Flags().StringVarP(&f.Config, flagConfig, "g", "", `The path to the file containing API endpoints and tokens for each provider.`)
-	cmd.Flags().StringVarP(&f.Kubeconfig, flagKubeconfig, "k", "", `The path to the directory containing the kubeconfigs for provider control planes.`)
 	cmd.Flags().StringVar(&f.Output, flagOutput, "", `The directory in which to store the cluster ID, kubeconfig, and provider of the created cluster.`)
 	cmd.Flags().StringVarP(&f.Installation, flagInstallation, "i", "", `The target management cluster type to be used ('aws', 'azure', 'kvm', 'gcp', 'openstack' or 'aws-china').`)
+	cmd.Flags().StringVar(&f.Provider, flagProvider, "", `The provider of the installation, labelling metrics and deciding whether the cluster can be labelled with pipeline metadata. Defaults to the installation.`)
+	cmd.Flags().StringVar(&f.Organization, flagOrganization, "", `The organization in which to create the cluster. Defaults to one selected with --organization-strategy.`)
+	cmd.Flags().StringVar(&f.OrganizationStrategy, flagOrganizationStrategy, key.OrganizationStrategyRandom, `How to select the organization among those available for testing ('random' or 'least-loaded').`)
+	cmd.Flags().BoolVar(&f.EphemeralOrganization, flagEphemeralOrganization, false, `Create a new organization for the cluster, which is deleted again by cleanup.`)
 	cmd.Flags().StringVarP(&f.Release, flagRelease, "r", "", `The semantic version of the release to be tested.`)
+	cmd.Flags().StringVar(&f.Credentials, flagCredentials, key.CredentialsCertificate, `The credentials written to the kubeconfig of the cluster ('certificate' or 'service-account'). Service account tokens are revoked by cleanup.`)
+	cmd.Flags().StringSliceVar(&f.CertificateOrganizations, flagCertificateOrganizations, []string{key.DefaultCertificateOrganization}, `The organizations, i.e. groups, of the client certificate in the kubeconfig of the cluster.`)
+	cmd.Flags().DurationVar(&f.CertificateTTL, flagCertificateTTL, key.DefaultCertificateTTL, `The lifetime of the client certificate in the kubeconfig of the cluster, rounded up to full hours.`)
+	cmd.Flags().StringVar(&f.ServiceAccountClusterRole, flagServiceAccountClusterRole, key.DefaultServiceAccountClusterRole, `The ClusterRole bound to the service account of the kubeconfig of the cluster when using service account credentials.`)
+	cmd.Flags().StringVar(&f.Spec, flagSpec, "", `The path to a YAML file defining node pools, control plane and labels of the cluster. Defaults to the cluster spec of the pipeline, if configured.`)
+	cmd.Flags().StringVarP(&f.Pipeline, flagPipeline, "t", key.DefaultPipelineName, `The name of the pipeline in which standup is currently running.`)
+	cmd.Flags().StringVar(&f.PipelineConfig, flagPipelineConfig, "", `The path to a YAML file containing pipeline configurations. Defaults to the built-in configurations.`)
+	cmd.Flags().DurationVar(&f.RollbackGracePeriod, flagRollbackGracePeriod, key.DefaultRollbackGracePeriod, `The time available to roll back created resources when interrupted. Zero disables rollback.`)
+
+	f.Kubeconfig.Init(cmd)
+	f.Metadata.Init(cmd)
+	f.Metrics.Init(cmd)
+	f.Tracing.Init(cmd)
 }
 
 func (f *flag) Validate() error {
@@ -39,6 +89,21 @@ func (f *flag) Validate() error {
 	if f.Output == "" {
 		return microerror.Maskf(invalidFlagError, "--%s is required", flagOutput)
 	}
+	if f.Organization != "" && f.EphemeralOrganization {
+		return microerror.Maskf(invalidFlagError, "--%s and --%s are mutually exclusive", flagOrganization, flagEphemeralOrganization)
+	}
+	if f.Credentials != key.CredentialsCertificate && f.Credentials != key.CredentialsServiceAccount {
+		return microerror.Maskf(invalidFlagError, "--%s must be one of %#q or %#q", flagCredentials, key.CredentialsCertificate, key.CredentialsServiceAccount)
+	}
+	if f.CertificateTTL <= 0 {
+		return microerror.Maskf(invalidFlagError, "--%s must be positive", flagCertificateTTL)
+	}
+	if f.Credentials == key.CredentialsServiceAccount && f.ServiceAccountClusterRole == "" {
+		return microerror.Maskf(invalidFlagError, "--%s must not be empty", flagServiceAccountClusterRole)
+	}
+	if f.OrganizationStrategy != key.OrganizationStrategyRandom && f.OrganizationStrategy != key.OrganizationStrategyLeastLoaded {
+		return microerror.Maskf(invalidFlagError, "--%s must be one of %#q or %#q", flagOrganizationStrategy, key.OrganizationStrategyRandom, key.OrganizationStrategyLeastLoaded)
+	}
 	if f.Release != "" {
 		f.Release = strings.TrimPrefix(f.Release, "v")
 		if _, err := semver.NewVersion(f.Release); err != nil {
@@ -0,0 +1,101 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+
+	"github.com/giantswarm/apiextensions/v2/pkg/apis/security/v1alpha1"
+	"github.com/giantswarm/microerror"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	utilrand "k8s.io/apimachinery/pkg/util/rand"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/giantswarm/standup/pkg/gsclient"
+	"github.com/giantswarm/standup/pkg/key"
+)
+
+// createEphemeralOrganization creates a uniquely named Organization CR for
+// this cluster only. It is labelled so cleanup deletes it with the cluster.
+func (r *runner) createEphemeralOrganization(ctx context.Context, ctrl client.Client) (string, error) {
+	organization := &v1alpha1.Organization{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: key.EphemeralOrganizationName(utilrand.String(5)),
+			Labels: map[string]string{
+				key.LabelEphemeralOrganization: "true",
+				key.LabelTesting:               "true",
+			},
+		},
+	}
+	organization.Labels, organization.Annotations = r.flag.Metadata.ObjectMeta(organization.Labels, organization.Annotations)
+
+	r.logger.LogCtx(ctx, "message", fmt.Sprintf("creating ephemeral organization %s", organization.Name))
+	err := ctrl.Create(ctx, organization)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+	r.logger.LogCtx(ctx, "message", fmt.Sprintf("created ephemeral organization %s", organization.Name))
+
+	return organization.Name, nil
+}
+
+// selectOrganization picks one of the organizations matching the pipeline's
+// organization selector using the configured strategy.
+func (r *runner) selectOrganization(ctx context.Context, ctrl client.Client, gsClient *gsclient.Client) (string, error) {
+	selector, err := labels.Parse(key.OrganizationSelectorForPipeline(r.flag.Pipeline))
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+	organizations := &v1alpha1.OrganizationList{}
+	err = ctrl.List(ctx, organizations, client.MatchingLabelsSelector{Selector: selector})
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+	if len(organizations.Items) == 0 {
+		return "", microerror.Maskf(notAvailableOrganizationError, "no organization matches selector %#q", selector.String())
+	}
+
+	var names []string
+	for _, o := range organizations.Items {
+		names = append(names, o.Name)
+	}
+
+	if r.flag.OrganizationStrategy == key.OrganizationStrategyLeastLoaded {
+		clusters, err := gsClient.ListClusters(ctx)
+		if err != nil {
+			return "", microerror.Mask(err)
+		}
+
+		organization, count := leastLoadedOrganization(names, clusters)
+		r.logger.LogCtx(ctx, "message", fmt.Sprintf("organization %s has the fewest clusters (%d)", organization, count))
+
+		return organization, nil
+	}
+
+	return names[rand.Intn(len(names))], nil //#nosec
+}
+
+// leastLoadedOrganization returns the organization owning the fewest of the
+// given clusters, together with that number. Ties are broken randomly so
+// parallel runs spread across organizations.
+func leastLoadedOrganization(organizations []string, clusters []gsclient.ClusterEntry) (string, int) {
+	counts := map[string]int{}
+	for _, c := range clusters {
+		counts[c.Owner]++
+	}
+
+	var candidates []string
+	min := -1
+	for _, o := range organizations {
+		switch {
+		case min == -1 || counts[o] < min:
+			min = counts[o]
+			candidates = []string{o}
+		case counts[o] == min:
+			candidates = append(candidates, o)
+		}
+	}
+
+	return candidates[rand.Intn(len(candidates))], min //#nosec
+}
@@ -0,0 +1,64 @@
+package cluster
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/giantswarm/standup/pkg/gsclient"
+)
+
+func Test_leastLoadedOrganization(t *testing.T) {
+	testCases := []struct {
+		name          string
+		organizations []string
+		clusters      []gsclient.ClusterEntry
+		expected      string
+		expectedCount int
+	}{
+		{
+			name:          "case 0: organization without clusters",
+			organizations: []string{"conformance-a", "conformance-b"},
+			clusters: []gsclient.ClusterEntry{
+				{ID: "abc12", Owner: "conformance-a"},
+			},
+			expected:      "conformance-b",
+			expectedCount: 0,
+		},
+		{
+			name:          "case 1: clusters of other organizations are ignored",
+			organizations: []string{"conformance-a", "conformance-b"},
+			clusters: []gsclient.ClusterEntry{
+				{ID: "abc12", Owner: "conformance-a"},
+				{ID: "def34", Owner: "conformance-b"},
+				{ID: "ghi56", Owner: "conformance-b"},
+				{ID: "jkl78", Owner: "giantswarm"},
+				{ID: "mno90", Owner: "giantswarm"},
+			},
+			expected:      "conformance-a",
+			expectedCount: 1,
+		},
+		{
+			name:          "case 2: single organization",
+			organizations: []string{"conformance-a"},
+			clusters: []gsclient.ClusterEntry{
+				{ID: "abc12", Owner: "conformance-a"},
+			},
+			expected:      "conformance-a",
+			expectedCount: 1,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			organization, count := leastLoadedOrganization(tc.organizations, tc.clusters)
+			if organization != tc.expected {
+				t.Fatalf("expected organization %s, got %s", tc.expected, organization)
+			}
+			if count != tc.expectedCount {
+				t.Fatalf("expected %d clusters, got %d", tc.expectedCount, count)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/giantswarm/apiextensions/v2/pkg/apis/release/v1alpha1"
+	"github.com/giantswarm/microerror"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/giantswarm/standup/pkg/key"
+)
+
+// referenceRelease records the given cluster on the Release CR it is created
+// on, or removes the reference again, so cleanup only deletes a release shared
+// by several clusters with the last of them. Each cluster has an annotation of
+// its own, so concurrent pipelines never conflict. A missing release is not
+// referenced.
+func (r *runner) referenceRelease(ctx context.Context, ctrl client.Client, releaseName, clusterID string, add bool) error {
+	var value interface{}
+	if add {
+		value = "true"
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]interface{}{
+				key.ReleaseClusterAnnotation(clusterID): value,
+			},
+		},
+	})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	release := &v1alpha1.Release{ObjectMeta: metav1.ObjectMeta{Name: releaseName}}
+	err = ctrl.Patch(ctx, release, client.RawPatch(types.MergePatchType, patch))
+	if apierrors.IsNotFound(err) {
+		r.logger.LogCtx(ctx, "level", "warning", "message", fmt.Sprintf("release CR %s does not exist", releaseName))
+		return nil
+	} else if err != nil {
+		return microerror.Mask(err)
+	}
+
+	if add {
+		r.logger.LogCtx(ctx, "message", fmt.Sprintf("referenced cluster %s on release CR %s", clusterID, releaseName))
+	} else {
+		r.logger.LogCtx(ctx, "message", fmt.Sprintf("removed reference of cluster %s from release CR %s", clusterID, releaseName))
+	}
+
+	return nil
+}
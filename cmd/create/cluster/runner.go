@@ -9,26 +9,37 @@ import (
 	"path/filepath"
 	"time"
 
+	releasev1alpha1 "github.com/giantswarm/apiextensions/v2/pkg/apis/release/v1alpha1"
 	"github.com/giantswarm/apiextensions/v2/pkg/apis/security/v1alpha1"
 	"github.com/giantswarm/backoff"
 	"github.com/giantswarm/microerror"
 	"github.com/giantswarm/micrologger"
 	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
-	"k8s.io/client-go/tools/clientcmd"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	"github.com/giantswarm/standup/pkg/clusterspec"
 	"github.com/giantswarm/standup/pkg/config"
+	"github.com/giantswarm/standup/pkg/credentials"
 	"github.com/giantswarm/standup/pkg/gsclient"
 	"github.com/giantswarm/standup/pkg/key"
+	"github.com/giantswarm/standup/pkg/metrics"
+	"github.com/giantswarm/standup/pkg/rollback"
+	"github.com/giantswarm/standup/pkg/semaphore"
+	"github.com/giantswarm/standup/pkg/tracing"
 )
 
 type runner struct {
-	flag   *flag
-	logger micrologger.Logger
-	stdout io.Writer
-	stderr io.Writer
+	flag     *flag
+	logger   micrologger.Logger
+	metrics  *metrics.Recorder
+	tracing  *tracing.Tracing
+	rollback *rollback.Stack
+	stdout   io.Writer
+	stderr   io.Writer
 }
 
 var Scheme = runtime.NewScheme()
@@ -37,6 +48,7 @@ func init() {
 	rand.Seed(time.Now().Unix())
 
 	schemeBuilder := runtime.SchemeBuilder{
+		releasev1alpha1.AddToScheme,
 		v1alpha1.AddToScheme,
 	}
 	err := schemeBuilder.AddToScheme(Scheme)
@@ -46,15 +58,68 @@ func init() {
 }
 
 func (r *runner) Run(cmd *cobra.Command, args []string) error {
-	ctx := context.Background()
+	ctx := cmd.Context()
 
 	err := r.flag.Validate()
 	if err != nil {
 		return microerror.Mask(err)
 	}
 
+	r.rollback, err = rollback.New(rollback.Config{
+		Logger:      r.logger,
+		GracePeriod: r.flag.RollbackGracePeriod,
+	})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	r.metrics, err = metrics.New(metrics.Config{
+		Logger: r.logger,
+
+		Command:     cmd.Name(),
+		File:        r.flag.Metrics.File,
+		Pushgateway: r.flag.Metrics.Pushgateway,
+	})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	// Metrics are flushed also when the command fails, without failing it.
+	defer func() {
+		err := r.metrics.Flush(context.Background())
+		if err != nil {
+			r.logger.LogCtx(ctx, "level", "warning", "message", "failed to flush metrics", "stack", microerror.JSON(err))
+		}
+	}()
+
+	traceparent, err := r.flag.Tracing.ResolveTraceparent(r.flag.Output)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	r.tracing, ctx, err = tracing.New(ctx, tracing.Config{
+		Logger: r.logger,
+
+		Command:     cmd.CommandPath(),
+		Endpoint:    r.flag.Tracing.Endpoint,
+		Traceparent: traceparent,
+	})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	// Spans are exported also when the command fails, without failing it.
+	// err holds the result of the command by the time this runs.
+	defer func() {
+		endErr := r.tracing.End(err)
+		if endErr != nil {
+			r.logger.LogCtx(ctx, "level", "warning", "message", "failed to export traces", "stack", microerror.JSON(endErr))
+		}
+	}()
+
 	err = r.run(ctx, cmd, args)
 	if err != nil {
+		// Remove what this invocation created if it has been interrupted.
+		// Errors are logged by the rollback, the original error is returned.
+		_ = r.rollback.RunIfInterrupted(ctx)
 		return microerror.Mask(err)
 	}
 
@@ -62,24 +127,28 @@ func (r *runner) Run(cmd *cobra.Command, args []string) error {
 }
 
 func (r *runner) run(ctx context.Context, _ *cobra.Command, _ []string) error {
-	kubeconfigPath := key.KubeconfigPath(r.flag.Kubeconfig, r.flag.Installation)
-
-	// Create REST config for the control plane
-	var restConfig *rest.Config
+	// Write trace context to filesystem, so the following commands join the trace
 	{
-		var err error
-		restConfig, err = clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
-			&clientcmd.ClientConfigLoadingRules{ExplicitPath: kubeconfigPath},
-			&clientcmd.ConfigOverrides{}).ClientConfig()
+		err := r.tracing.WriteTraceparent(ctx, r.flag.Output)
 		if err != nil {
 			return microerror.Mask(err)
 		}
 	}
 
-	ctrl, err := client.New(rest.CopyConfig(restConfig), client.Options{Scheme: Scheme})
-	if err != nil {
-		return microerror.Mask(err)
+	if r.flag.PipelineConfig != "" {
+		pipelineConfigs, err := config.LoadPipelineConfigs(r.flag.PipelineConfig)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		key.RegisterPipelineConfigs(pipelineConfigs)
 	}
+	_, pipelineConfig := key.GetPipelineConfigByName(r.flag.Pipeline)
+
+	r.metrics.SetLabels(metrics.Labels{
+		Installation: r.flag.Installation,
+		Provider:     r.flag.Provider,
+		Release:      r.flag.Release,
+	})
 
 	var providerConfig *config.ProviderConfig
 	{
@@ -90,6 +159,21 @@ func (r *runner) run(ctx context.Context, _ *cobra.Command, _ []string) error {
 		}
 	}
 
+	// Create REST config for the control plane
+	var restConfig *rest.Config
+	{
+		var err error
+		restConfig, err = r.flag.Kubeconfig.RESTConfig(r.flag.Installation, providerConfig.Kubeconfig)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	ctrl, err := client.New(rest.CopyConfig(restConfig), client.Options{Scheme: Scheme})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
 	// Create a GS API client for managing tenant clusters
 	var gsClient *gsclient.Client
 	{
@@ -109,35 +193,195 @@ func (r *runner) run(ctx context.Context, _ *cobra.Command, _ []string) error {
 		}
 	}
 
+	// Wait for a free slot if the number of test clusters on the installation is limited.
+	var capacity *semaphore.Semaphore
+	var capacityHolder string
+	if providerConfig.Capacity.Slots > 0 {
+		k8sClient, err := kubernetes.NewForConfig(rest.CopyConfig(restConfig))
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		c := semaphore.Config{
+			K8sClient: k8sClient,
+			Logger:    r.logger,
+
+			Name:            key.CapacityConfigMapName,
+			Namespace:       key.CapacityConfigMapNamespace,
+			Slots:           providerConfig.Capacity.Slots,
+			MaxSlotLifetime: providerConfig.Capacity.MaxSlotLifetime.Duration,
+		}
+
+		capacity, err = semaphore.New(c)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		capacityHolder, err = key.CapacityHolderName()
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		r.logger.LogCtx(ctx, "message", fmt.Sprintf("acquiring one of %d capacity slots on installation %s", providerConfig.Capacity.Slots, r.flag.Installation))
+		stepCtx, end := tracing.Start(ctx, metrics.StepCapacity)
+		done := r.metrics.Step(metrics.StepCapacity)
+		err = capacity.Acquire(stepCtx, capacityHolder)
+		done(err)
+		end(err)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		heartbeatCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		go capacity.Heartbeat(heartbeatCtx, capacityHolder)
+
+		// Free the slot again unless it was handed over to the created cluster.
+		defer func() {
+			if capacityHolder == "" {
+				return
+			}
+			// The command context may already be cancelled by an interrupt.
+			releaseCtx, cancel := context.WithTimeout(context.Background(), key.DefaultRollbackGracePeriod)
+			defer cancel()
+
+			err := capacity.Release(releaseCtx, capacityHolder)
+			if err != nil {
+				r.logger.LogCtx(ctx, "level", "warning", "message", "failed to release capacity slot", "stack", microerror.JSON(err))
+			}
+		}()
+	}
+
+	// clusterID is set as soon as a cluster has been created, it is declared
+	// here so rollback steps can tell whether a cluster exists.
+	var clusterID string
+
 	var organization string
 	{
-		labelSelector := client.MatchingLabels{
-			"giantswarm.io/conformance-testing": "true",
+		switch {
+		case r.flag.Organization != "":
+			organization = r.flag.Organization
+		case r.flag.EphemeralOrganization:
+			organization, err = r.createEphemeralOrganization(ctx, ctrl)
+			if err == nil {
+				name := organization
+				r.rollback.Add(fmt.Sprintf("delete ephemeral organization %s", name), func(ctx context.Context) error {
+					// An organization with a cluster in deletion is left to cleanup.
+					if clusterID != "" {
+						return nil
+					}
+					err := ctrl.Delete(ctx, &v1alpha1.Organization{ObjectMeta: metav1.ObjectMeta{Name: name}})
+					if err != nil {
+						return microerror.Mask(client.IgnoreNotFound(err))
+					}
+					return nil
+				})
+			}
+		default:
+			organization, err = r.selectOrganization(ctx, ctrl, gsClient)
 		}
-		organizations := &v1alpha1.OrganizationList{}
-		err = ctrl.List(ctx, organizations, labelSelector)
 		if err != nil {
 			return microerror.Mask(err)
 		}
-		if len(organizations.Items) == 0 {
-			return microerror.Mask(notAvailableOrganizationError)
+	}
+
+	// Write organization to filesystem
+	{
+		organizationPath := filepath.Join(r.flag.Output, "organization")
+		r.logger.LogCtx(ctx, "message", fmt.Sprintf("writing organization to path %s", organizationPath))
+		err := os.WriteFile(organizationPath, []byte(organization), 0644) //#nosec
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	var spec *clusterspec.Spec
+	{
+		if r.flag.Spec != "" {
+			var err error
+			spec, err = clusterspec.Load(r.flag.Spec)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+		} else {
+			spec = pipelineConfig.ClusterSpec
 		}
 
-		organization = organizations.Items[rand.Intn(len(organizations.Items))].Name //#nosec
+		// Clusters can only be labelled through a v5 cluster definition, which is
+		// only used when a spec is given. Without one an empty spec keeps the
+		// defaults of gsctl where v5 is supported. Otherwise the metadata is only
+		// recorded in the cluster description.
+		if !r.flag.Metadata.IsEmpty() {
+			provider := r.flag.Provider
+			if provider == "" {
+				provider = r.flag.Installation
+			}
+			if spec == nil && key.SupportsClusterDefinitionV5(provider, r.flag.Release) {
+				spec = &clusterspec.Spec{}
+			}
+			if spec != nil {
+				spec.Labels, _ = r.flag.Metadata.ObjectMeta(spec.Labels, nil)
+			}
+		}
 	}
 
 	// Create the cluster under test
-	var clusterID string
 	r.logger.LogCtx(ctx, "message", fmt.Sprintf("creating cluster using target release %s and organization %s", r.flag.Release, organization))
 	{
 		var err error
-		clusterID, err = gsClient.CreateCluster(ctx, organization, r.flag.Release)
+		stepCtx, end := tracing.Start(ctx, metrics.StepClusterCreation)
+		done := r.metrics.Step(metrics.StepClusterCreation)
+		clusterID, err = gsClient.CreateCluster(stepCtx, organization, r.flag.Metadata.Describe(r.flag.Release), r.flag.Release, spec)
+		done(err)
+		end(err)
+		// A cluster may have been created even though creation failed.
+		if clusterID != "" {
+			id := clusterID
+			r.rollback.Add(fmt.Sprintf("delete cluster %s", id), func(ctx context.Context) error {
+				err := gsClient.DeleteCluster(ctx, id)
+				if gsclient.IsClusterNotFoundError(err) {
+					return nil
+				} else if err != nil {
+					return microerror.Mask(err)
+				}
+				return nil
+			})
+		}
 		if err != nil {
 			return microerror.Mask(err)
 		}
 	}
 	r.logger.LogCtx(ctx, "message", fmt.Sprintf("created cluster %s", clusterID))
 
+	// Reference the cluster on its release, so cleanup of other clusters on
+	// the same release keeps it. CAPI releases are not created by standup.
+	if !key.IsCapiRelease(r.flag.Release) {
+		releaseName := fmt.Sprintf("v%s", r.flag.Release)
+		err := r.referenceRelease(ctx, ctrl, releaseName, clusterID, true)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		id := clusterID
+		r.rollback.Add(fmt.Sprintf("remove reference of cluster %s from release %s", id, releaseName), func(ctx context.Context) error {
+			return microerror.Mask(r.referenceRelease(ctx, ctrl, releaseName, id, false))
+		})
+	}
+
+	// Hand the capacity slot over to the cluster, so cleanup releases it.
+	if capacity != nil {
+		err := capacity.Rename(ctx, capacityHolder, clusterID)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		capacityHolder = ""
+
+		id := clusterID
+		r.rollback.Add(fmt.Sprintf("release capacity slot of cluster %s", id), func(ctx context.Context) error {
+			return microerror.Mask(capacity.Release(ctx, id))
+		})
+	}
+
 	// Write cluster ID to filesystem
 	{
 		clusterIDPath := filepath.Join(r.flag.Output, "cluster-id")
@@ -148,22 +392,99 @@ func (r *runner) run(ctx context.Context, _ *cobra.Command, _ []string) error {
 		}
 	}
 
+	// Write the credentials to filesystem, so cleanup knows whether to revoke them
+	{
+		credentialsPath := filepath.Join(r.flag.Output, "credentials")
+		r.logger.LogCtx(ctx, "message", fmt.Sprintf("writing credentials to path %s", credentialsPath))
+		err := os.WriteFile(credentialsPath, []byte(r.flag.Credentials), 0644) //#nosec
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
 	clusterKubeconfigPath := filepath.Join(r.flag.Output, "kubeconfig")
 	r.logger.LogCtx(ctx, "message", fmt.Sprintf("creating and writing kubeconfig for cluster %s to path %s", clusterID, clusterKubeconfigPath))
+	// With service account credentials, the certificate is only used to create the service account
+	// and never written to disk.
+	var adminRestConfig *rest.Config
 	{
 		o := func() error {
 			// Create a keypair and kubeconfig for the new tenant cluster
-			err := gsClient.CreateKubeconfig(ctx, clusterID, clusterKubeconfigPath)
+			var err error
+			if r.flag.Credentials == key.CredentialsServiceAccount {
+				adminRestConfig, err = gsClient.CreateRESTConfig(ctx, clusterID, gsclient.KubeconfigOptions{
+					CertificateOrganizations: []string{key.DefaultCertificateOrganization},
+					TTL:                      time.Hour,
+				})
+			} else {
+				err = gsClient.CreateKubeconfig(ctx, clusterID, clusterKubeconfigPath, gsclient.KubeconfigOptions{
+					CertificateOrganizations: r.flag.CertificateOrganizations,
+					TTL:                      r.flag.CertificateTTL,
+				})
+			}
 			if err != nil {
 				r.logger.LogCtx(ctx, "message", "error creating kubeconfig", "error", err)
 				return microerror.Mask(err)
 			}
 			return nil
 		}
-		// Retry basically forever, the tekton task will determine maximum runtime.
-		b := backoff.NewMaxRetries(^uint64(0), 20*time.Second)
+		// Retry until the pipeline's cluster timeout, or basically forever if there is none.
+		b := key.WaitBackOff(ctx, pipelineConfig.Timeouts.Cluster.Duration, 20*time.Second)
+
+		stepCtx, end := tracing.Start(ctx, metrics.StepKubeconfig)
+		done := r.metrics.Step(metrics.StepKubeconfig)
+		err := backoff.RetryNotify(o, b, tracing.Notify(stepCtx))
+		done(err)
+		end(err)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	if r.flag.Credentials == key.CredentialsServiceAccount {
+		r.logger.LogCtx(ctx, "message", fmt.Sprintf("creating service account bound to cluster role %s in cluster %s", r.flag.ServiceAccountClusterRole, clusterID))
+
+		k8sClient, err := kubernetes.NewForConfig(adminRestConfig)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		c := credentials.Config{
+			K8sClient: k8sClient,
+			Logger:    r.logger,
+
+			Server: adminRestConfig.Host,
+			CAData: adminRestConfig.CAData,
+		}
+
+		serviceAccount, err := credentials.New(c)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		var kubeconfig []byte
+		o := func() error {
+			// The API of the new cluster may not be reachable yet.
+			kubeconfig, err = serviceAccount.Create(ctx, r.flag.ServiceAccountClusterRole)
+			if err != nil {
+				r.logger.LogCtx(ctx, "message", "service account not yet created", "error", err)
+				return microerror.Mask(err)
+			}
+			return nil
+		}
+		// Retry until the pipeline's cluster timeout, or basically forever if there is none.
+		b := key.WaitBackOff(ctx, pipelineConfig.Timeouts.Cluster.Duration, 20*time.Second)
+
+		stepCtx, end := tracing.Start(ctx, metrics.StepCredentials)
+		done := r.metrics.Step(metrics.StepCredentials)
+		err = backoff.RetryNotify(o, b, tracing.Notify(stepCtx))
+		done(err)
+		end(err)
+		if err != nil {
+			return microerror.Mask(err)
+		}
 
-		err := backoff.Retry(o, b)
+		err = os.WriteFile(clusterKubeconfigPath, kubeconfig, 0600)
 		if err != nil {
 			return microerror.Mask(err)
 		}
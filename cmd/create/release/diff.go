@@ -0,0 +1,63 @@
+package release
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/giantswarm/apiextensions/v2/pkg/apis/release/v1alpha1"
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/standup/pkg/releases"
+)
+
+// writeDiff writes the changes of the release under test compared to the
+// previous release of the provider to the output directory for reviewers, as
+// Markdown and JSON. The first release of a provider has nothing to compare to.
+func (r *runner) writeDiff(ctx context.Context, provider string, release *v1alpha1.Release) error {
+	diff, skipped, err := releases.CompareToPrevious(r.flag.Releases, provider, release)
+	for _, name := range skipped {
+		r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("Unable to parse semver version from %q", name))
+	}
+	if releases.IsReleaseNotFound(err) {
+		r.logger.LogCtx(ctx, "level", "warning", "message", fmt.Sprintf("not writing release diff: %s", microerror.Pretty(err, false)))
+		return nil
+	} else if err != nil {
+		return microerror.Mask(err)
+	}
+	r.logger.LogCtx(ctx, "message", fmt.Sprintf("release %s changes %d apps and components of %s", diff.To, len(diff.Changes), diff.From))
+
+	for _, c := range diff.Downgrades() {
+		r.logger.LogCtx(ctx, "level", "warning", "message", fmt.Sprintf("%s %s is downgraded from %s to %s", c.Kind, c.Name, c.From.Version, c.To.Version))
+	}
+
+	var markdown, json bytes.Buffer
+	err = diff.WriteMarkdown(&markdown)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	err = diff.WriteJSON(&json)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	files := []struct {
+		name string
+		data []byte
+	}{
+		{name: "release-diff.md", data: markdown.Bytes()},
+		{name: "release-diff.json", data: json.Bytes()},
+	}
+	for _, f := range files {
+		diffPath := filepath.Join(r.flag.Output, f.name)
+		r.logger.LogCtx(ctx, "message", fmt.Sprintf("writing release diff to path %s", diffPath))
+		err := os.WriteFile(diffPath, f.data, 0644) //#nosec
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	return nil
+}
@@ -17,13 +17,17 @@ import (
 	"github.com/giantswarm/microerror"
 	"github.com/giantswarm/micrologger"
 	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/rest"
-	"k8s.io/client-go/tools/clientcmd"
 	"sigs.k8s.io/yaml"
 
+	"github.com/giantswarm/standup/pkg/config"
 	"github.com/giantswarm/standup/pkg/git"
 	"github.com/giantswarm/standup/pkg/key"
+	"github.com/giantswarm/standup/pkg/metrics"
+	"github.com/giantswarm/standup/pkg/rollback"
+	"github.com/giantswarm/standup/pkg/tracing"
 )
 
 // Following pattern for release name has been taken from CRD validation:
@@ -31,22 +35,78 @@ import (
 var releaseNamePattern = regexp.MustCompile(`^v(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)(-[\.0-9a-zA-Z]*)?$`)
 
 type runner struct {
-	flag   *flag
-	logger micrologger.Logger
-	stdout io.Writer
-	stderr io.Writer
+	flag     *flag
+	logger   micrologger.Logger
+	metrics  *metrics.Recorder
+	tracing  *tracing.Tracing
+	rollback *rollback.Stack
+	stdout   io.Writer
+	stderr   io.Writer
 }
 
 func (r *runner) Run(cmd *cobra.Command, args []string) error {
-	ctx := context.Background()
+	ctx := cmd.Context()
 
 	err := r.flag.Validate()
 	if err != nil {
 		return microerror.Mask(err)
 	}
 
+	r.rollback, err = rollback.New(rollback.Config{
+		Logger:      r.logger,
+		GracePeriod: r.flag.RollbackGracePeriod,
+	})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	r.metrics, err = metrics.New(metrics.Config{
+		Logger: r.logger,
+
+		Command:     cmd.Name(),
+		File:        r.flag.Metrics.File,
+		Pushgateway: r.flag.Metrics.Pushgateway,
+	})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	// Metrics are flushed also when the command fails, without failing it.
+	defer func() {
+		err := r.metrics.Flush(context.Background())
+		if err != nil {
+			r.logger.LogCtx(ctx, "level", "warning", "message", "failed to flush metrics", "stack", microerror.JSON(err))
+		}
+	}()
+
+	traceparent, err := r.flag.Tracing.ResolveTraceparent(r.flag.Output)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	r.tracing, ctx, err = tracing.New(ctx, tracing.Config{
+		Logger: r.logger,
+
+		Command:     cmd.CommandPath(),
+		Endpoint:    r.flag.Tracing.Endpoint,
+		Traceparent: traceparent,
+	})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	// Spans are exported also when the command fails, without failing it.
+	// err holds the result of the command by the time this runs.
+	defer func() {
+		endErr := r.tracing.End(err)
+		if endErr != nil {
+			r.logger.LogCtx(ctx, "level", "warning", "message", "failed to export traces", "stack", microerror.JSON(endErr))
+		}
+	}()
+
 	err = r.run(ctx, cmd, args)
 	if err != nil {
+		// Remove what this invocation created if it has been interrupted.
+		// Errors are logged by the rollback, the original error is returned.
+		_ = r.rollback.RunIfInterrupted(ctx)
 		return microerror.Mask(err)
 	}
 
@@ -81,6 +141,23 @@ func findReleaseInDiff(diff string) (releasePath string, provider string, err er
 }
 
 func (r *runner) run(ctx context.Context, _ *cobra.Command, _ []string) error {
+	// Write trace context to filesystem, so the following commands join the trace
+	{
+		err := r.tracing.WriteTraceparent(ctx, r.flag.Output)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	if r.flag.PipelineConfig != "" {
+		pipelineConfigs, err := config.LoadPipelineConfigs(r.flag.PipelineConfig)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		key.RegisterPipelineConfigs(pipelineConfigs)
+	}
+	_, pipelineConfig := key.GetPipelineConfigByName(r.flag.Pipeline)
+
 	var release v1alpha1.Release
 	var provider string
 	var installation string
@@ -91,18 +168,18 @@ func (r *runner) run(ctx context.Context, _ *cobra.Command, _ []string) error {
 		{
 			// Tekton checks out the current commit in detached HEAD state with --depth=1.
 			// This means we need to fetch origin/master before we can determine the changed files.
-			err := git.Fetch(r.flag.Releases)
+			err := git.Fetch(ctx, r.flag.Releases)
 			if err != nil {
 				return microerror.Mask(err)
 			}
 
-			mergeBase, err := git.MergeBase(r.flag.Releases)
+			mergeBase, err := git.MergeBase(ctx, r.flag.Releases)
 			if err != nil {
 				return microerror.Mask(err)
 			}
 
 			// Use "git diff" to find the release under test
-			diff, err := git.Diff(r.flag.Releases, mergeBase)
+			diff, err := git.Diff(ctx, r.flag.Releases, mergeBase)
 			if err != nil {
 				return microerror.Mask(err)
 			}
@@ -136,6 +213,11 @@ func (r *runner) run(ctx context.Context, _ *cobra.Command, _ []string) error {
 			return microerror.Mask(err)
 		}
 
+		err = r.writeDiff(ctx, provider, &release)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
 		// CAPI releases are a special case. We don't create a new release but reuse existing one.
 		if !key.IsCapiRelease(release.Name) {
 			// Randomize the name to avoid duplicate names.
@@ -148,19 +230,33 @@ func (r *runner) run(ctx context.Context, _ *cobra.Command, _ []string) error {
 			if release.Labels == nil {
 				release.Labels = map[string]string{}
 			}
-			release.Labels["giantswarm.io/testing"] = "true"
+			release.Labels[key.LabelTesting] = "true"
+
+			// Record the pipeline run owning the release.
+			release.Labels, release.Annotations = r.flag.Metadata.ObjectMeta(release.Labels, release.Annotations)
 		}
 	}
 
-	kubeconfigPath := key.KubeconfigPath(r.flag.Kubeconfig, installation)
+	r.metrics.SetLabels(metrics.Labels{
+		Installation: installation,
+		Provider:     provider,
+		Release:      strings.TrimPrefix(release.Name, "v"),
+	})
+
+	var kubeconfigConfig config.KubeconfigConfig
+	{
+		var err error
+		kubeconfigConfig, err = config.LoadKubeconfigConfig(r.flag.Config, installation)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
 
 	// Create REST config for the control plane
 	var restConfig *rest.Config
 	{
 		var err error
-		restConfig, err = clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
-			&clientcmd.ClientConfigLoadingRules{ExplicitPath: kubeconfigPath},
-			&clientcmd.ConfigOverrides{}).ClientConfig()
+		restConfig, err = r.flag.Kubeconfig.RESTConfig(installation, kubeconfigConfig)
 		if err != nil {
 			return microerror.Mask(err)
 		}
@@ -204,10 +300,25 @@ func (r *runner) run(ctx context.Context, _ *cobra.Command, _ []string) error {
 		// Create the Release CR
 		r.logger.LogCtx(ctx, "message", "creating release CR")
 		{
-			_, err := k8sClient.G8sClient().ReleaseV1alpha1().Releases().Create(ctx, &release, v1.CreateOptions{})
+			stepCtx, end := tracing.Start(ctx, metrics.StepReleaseCreation)
+			done := r.metrics.Step(metrics.StepReleaseCreation)
+			_, err := k8sClient.G8sClient().ReleaseV1alpha1().Releases().Create(stepCtx, &release, v1.CreateOptions{})
+			done(err)
+			end(err)
 			if err != nil {
 				return microerror.Mask(err)
 			}
+
+			releaseName := release.Name
+			r.rollback.Add(fmt.Sprintf("delete release CR %s", releaseName), func(ctx context.Context) error {
+				err := k8sClient.G8sClient().ReleaseV1alpha1().Releases().Delete(ctx, releaseName, v1.DeleteOptions{})
+				if apierrors.IsNotFound(err) {
+					return nil
+				} else if err != nil {
+					return microerror.Mask(err)
+				}
+				return nil
+			})
 		}
 		r.logger.LogCtx(ctx, "message", "created release CR")
 	}
@@ -237,10 +348,14 @@ func (r *runner) run(ctx context.Context, _ *cobra.Command, _ []string) error {
 
 			return nil
 		}
-		// Retry basically forever, the tekton task will determine maximum runtime.
-		b := backoff.NewMaxRetries(^uint64(0), 20*time.Second)
-
-		err := backoff.Retry(o, b)
+		// Retry until the pipeline's release timeout, or basically forever if there is none.
+		b := key.WaitBackOff(ctx, pipelineConfig.Timeouts.Release.Duration, 20*time.Second)
+
+		stepCtx, end := tracing.Start(ctx, metrics.StepReleaseReady)
+		done := r.metrics.Step(metrics.StepReleaseReady)
+		err := backoff.RetryNotify(o, b, tracing.Notify(stepCtx))
+		done(err)
+		end(err)
 		if err != nil {
 			return microerror.Mask(err)
 		}
@@ -29,15 +29,6 @@ func IsInvalidRequest(err error) bool {
 	return microerror.Cause(err) == invalidRequestError
 }
 
-var releaseNotFoundError = &microerror.Error{
-	Kind: "releaseNotFoundError",
-}
-
-// IsReleaseNotFound asserts releaseNotFoundError.
-func IsReleaseNotFound(err error) bool {
-	return microerror.Cause(err) == releaseNotFoundError
-}
-
 var releaseNotReadyError = &microerror.Error{
 	Kind: "releaseNotReadyError",
 }
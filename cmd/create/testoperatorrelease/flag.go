@@ -1,49 +1,65 @@
 package testoperatorrelease
 
 import (
+	"time"
+
 	"github.com/giantswarm/microerror"
 	"github.com/spf13/cobra"
 
 	"github.com/giantswarm/standup/pkg/key"
+	"github.com/giantswarm/standup/pkg/kubeconfig"
+	"github.com/giantswarm/standup/pkg/metrics"
+	"github.com/giantswarm/standup/pkg/pipelinerun"
+	"github.com/giantswarm/standup/pkg/tracing"
 )
 
 const (
-	flagConfig       = "config"
-	flagKubeconfig   = "kubeconfig"
-	flagOperatorPath = "operator-path"
-	flagOutput       = "output"
-	flagPipeline     = "pipeline"
-	flagProvider     = "provider"
-	flagReleasesPath = "releases-path"
+	flagConfig              = "config"
+	flagOperatorPath        = "operator-path"
+	flagOutput              = "output"
+	flagPipeline            = "pipeline"
+	flagPipelineConfig      = "pipeline-config"
+	flagProvider            = "provider"
+	flagReleasesPath        = "releases-path"
+	flagRollbackGracePeriod = "rollback-grace-period"
 )
 
 type flag struct {
-	Config       string
-	Kubeconfig   string
-	OperatorPath string
-	Output       string
-	Pipeline     string
-	Provider     string
-	ReleasesPath string
+	Config              string
+	OperatorPath        string
+	Output              string
+	Pipeline            string
+	PipelineConfig      string
+	Provider            string
+	ReleasesPath        string
+	RollbackGracePeriod time.Duration
+
+	Kubeconfig kubeconfig.Flags
+	Metadata   pipelinerun.Metadata
+	Metrics    metrics.Flags
+	Tracing    tracing.Flags
 }
 
 func (f *flag) Init(cmd *cobra.Command) {
 	cmd.Flags().StringVarP(&f.Config, flagConfig, "g", "", `The path to the file containing API endpoints and tokens for each provider.`)
-	cmd.Flags().StringVarP(&f.Kubeconfig, flagKubeconfig, "k", "", `The path to the directory containing the kubeconfigs for provider control planes.`)
 	cmd.Flags().StringVar(&f.OperatorPath, flagOperatorPath, "", `The path of the provider operator repo on the local filesystem.`)
 	cmd.Flags().StringVar(&f.Output, flagOutput, "", `The directory in which to store the release name of the created release.`)
 	cmd.Flags().StringVar(&f.Provider, flagProvider, "", `The cloud provider to clone the release for.`)
 	cmd.Flags().StringVar(&f.ReleasesPath, flagReleasesPath, "", `The path of the releases repo on the local filesystem.`)
 	cmd.Flags().StringVarP(&f.Pipeline, flagPipeline, "t", key.DefaultPipelineName, `The name of the pipeline in which standup is currently running.`)
+	cmd.Flags().StringVar(&f.PipelineConfig, flagPipelineConfig, "", `The path to a YAML file containing pipeline configurations. Defaults to the built-in configurations.`)
+	cmd.Flags().DurationVar(&f.RollbackGracePeriod, flagRollbackGracePeriod, key.DefaultRollbackGracePeriod, `The time available to roll back created resources when interrupted. Zero disables rollback.`)
+
+	f.Kubeconfig.Init(cmd)
+	f.Metadata.Init(cmd)
+	f.Metrics.Init(cmd)
+	f.Tracing.Init(cmd)
 }
 
 func (f *flag) Validate() error {
 	if f.Config == "" {
 		return microerror.Maskf(invalidFlagError, "--%s is required", flagConfig)
 	}
-	if f.Kubeconfig == "" {
-		return microerror.Maskf(invalidFlagError, "--%s is required", flagKubeconfig)
-	}
 	if f.Output == "" {
 		return microerror.Maskf(invalidFlagError, "--%s is required", flagOutput)
 	}
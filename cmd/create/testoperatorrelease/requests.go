@@ -3,47 +3,21 @@ package testoperatorrelease
 import (
 	"context"
 	"fmt"
-	"os"
-	"path/filepath"
 	"strings"
 
 	"github.com/Masterminds/semver/v3"
-	"sigs.k8s.io/yaml"
 
 	"github.com/giantswarm/apiextensions/v2/pkg/apis/release/v1alpha1"
 	"github.com/giantswarm/microerror"
-)
-
-type apprequest struct {
-	Name    string `json:"name"`
-	Version string `json:"version"`
-	Issue   string `json:"issue"`
-}
-
-type request struct {
-	Name     string       `json:"name"`
-	Requests []apprequest `json:"requests"`
-}
 
-type requests struct {
-	Releases []request `json:"releases"`
-}
+	"github.com/giantswarm/standup/pkg/releases"
+)
 
 func (r *runner) updateFromRequests(ctx context.Context, release *v1alpha1.Release) error {
-	reqs := requests{}
 	// Parse requests from the requests.yaml file.
-	{
-		requestsPath := filepath.Join(r.flag.ReleasesPath, r.flag.Provider, "requests.yaml")
-
-		releaseYAML, err := os.ReadFile(requestsPath)
-		if err != nil {
-			return microerror.Mask(err)
-		}
-
-		err = yaml.Unmarshal(releaseYAML, &reqs)
-		if err != nil {
-			return microerror.Mask(err)
-		}
+	reqs, err := releases.LoadRequests(r.flag.ReleasesPath, r.flag.Provider)
+	if err != nil {
+		return microerror.Mask(err)
 	}
 
 	targetVersion, err := semver.NewVersion(strings.TrimPrefix(release.Name, "v"))
@@ -114,7 +88,7 @@ func (r *runner) updateFromRequests(ctx context.Context, release *v1alpha1.Relea
 }
 
 // The mergeRequirements func takes a requests file and computes the list of applications and their minimum version.
-func (r *runner) mergeRequirements(ctx context.Context, reqs requests, targetVersion semver.Version) (map[string]string, error) {
+func (r *runner) mergeRequirements(ctx context.Context, reqs releases.Requests, targetVersion semver.Version) (map[string]string, error) {
 	apps := map[string]string{}
 
 	for _, req := range reqs.Releases {
@@ -8,24 +8,26 @@ import (
 
 	"github.com/Masterminds/semver/v3"
 	"github.com/giantswarm/micrologger"
+
+	"github.com/giantswarm/standup/pkg/releases"
 )
 
 func TestMergeRequirements(t *testing.T) {
 	testCases := []struct {
 		name          string
 		targetVersion string
-		requests      requests
+		requests      releases.Requests
 		expected      map[string]string
 		errorMatcher  func(error) bool
 	}{
 		{
 			name:          "case 0: >= app specified twice",
 			targetVersion: "13.0.0",
-			requests: requests{
-				Releases: []request{
+			requests: releases.Requests{
+				Releases: []releases.RequestedRelease{
 					{
 						Name: ">= 13.0.0",
-						Requests: []apprequest{
+						Requests: []releases.Request{
 							{
 								Name:    "app-operator",
 								Version: ">= 1.1.0",
@@ -34,7 +36,7 @@ func TestMergeRequirements(t *testing.T) {
 					},
 					{
 						Name: ">= 13.0.0",
-						Requests: []apprequest{
+						Requests: []releases.Request{
 							{
 								Name:    "app-operator",
 								Version: ">= 1.0.0",
@@ -51,11 +53,11 @@ func TestMergeRequirements(t *testing.T) {
 		{
 			name:          "case 1: >= app specified once",
 			targetVersion: "13.0.0",
-			requests: requests{
-				Releases: []request{
+			requests: releases.Requests{
+				Releases: []releases.RequestedRelease{
 					{
 						Name: ">= 13.0.0",
-						Requests: []apprequest{
+						Requests: []releases.Request{
 							{
 								Name:    "app-operator",
 								Version: ">= 1.1.0",
@@ -72,11 +74,11 @@ func TestMergeRequirements(t *testing.T) {
 		{
 			name:          "case 2: >= two different apps",
 			targetVersion: "13.0.0",
-			requests: requests{
-				Releases: []request{
+			requests: releases.Requests{
+				Releases: []releases.RequestedRelease{
 					{
 						Name: ">= 13.0.0",
-						Requests: []apprequest{
+						Requests: []releases.Request{
 							{
 								Name:    "app-operator",
 								Version: ">= 1.1.0",
@@ -85,7 +87,7 @@ func TestMergeRequirements(t *testing.T) {
 					},
 					{
 						Name: ">= 13.0.0",
-						Requests: []apprequest{
+						Requests: []releases.Request{
 							{
 								Name:    "azure-operator",
 								Version: ">= 2.1.0",
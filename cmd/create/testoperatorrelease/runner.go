@@ -19,13 +19,17 @@ import (
 	"github.com/giantswarm/microerror"
 	"github.com/giantswarm/micrologger"
 	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/rest"
-	"k8s.io/client-go/tools/clientcmd"
-	"sigs.k8s.io/yaml"
 
+	"github.com/giantswarm/standup/pkg/config"
 	"github.com/giantswarm/standup/pkg/git"
 	"github.com/giantswarm/standup/pkg/key"
+	"github.com/giantswarm/standup/pkg/metrics"
+	"github.com/giantswarm/standup/pkg/releases"
+	"github.com/giantswarm/standup/pkg/rollback"
+	"github.com/giantswarm/standup/pkg/tracing"
 )
 
 // Following pattern for release name has been taken from CRD validation:
@@ -33,22 +37,78 @@ import (
 var releaseNamePattern = regexp.MustCompile(`^v(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)(-[\.0-9a-zA-Z]*)?$`)
 
 type runner struct {
-	flag   *flag
-	logger micrologger.Logger
-	stdout io.Writer
-	stderr io.Writer
+	flag     *flag
+	logger   micrologger.Logger
+	metrics  *metrics.Recorder
+	tracing  *tracing.Tracing
+	rollback *rollback.Stack
+	stdout   io.Writer
+	stderr   io.Writer
 }
 
 func (r *runner) Run(cmd *cobra.Command, args []string) error {
-	ctx := context.Background()
+	ctx := cmd.Context()
 
 	err := r.flag.Validate()
 	if err != nil {
 		return microerror.Mask(err)
 	}
 
+	r.rollback, err = rollback.New(rollback.Config{
+		Logger:      r.logger,
+		GracePeriod: r.flag.RollbackGracePeriod,
+	})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	r.metrics, err = metrics.New(metrics.Config{
+		Logger: r.logger,
+
+		Command:     cmd.Name(),
+		File:        r.flag.Metrics.File,
+		Pushgateway: r.flag.Metrics.Pushgateway,
+	})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	// Metrics are flushed also when the command fails, without failing it.
+	defer func() {
+		err := r.metrics.Flush(context.Background())
+		if err != nil {
+			r.logger.LogCtx(ctx, "level", "warning", "message", "failed to flush metrics", "stack", microerror.JSON(err))
+		}
+	}()
+
+	traceparent, err := r.flag.Tracing.ResolveTraceparent(r.flag.Output)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	r.tracing, ctx, err = tracing.New(ctx, tracing.Config{
+		Logger: r.logger,
+
+		Command:     cmd.CommandPath(),
+		Endpoint:    r.flag.Tracing.Endpoint,
+		Traceparent: traceparent,
+	})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	// Spans are exported also when the command fails, without failing it.
+	// err holds the result of the command by the time this runs.
+	defer func() {
+		endErr := r.tracing.End(err)
+		if endErr != nil {
+			r.logger.LogCtx(ctx, "level", "warning", "message", "failed to export traces", "stack", microerror.JSON(endErr))
+		}
+	}()
+
 	err = r.run(ctx, cmd, args)
 	if err != nil {
+		// Remove what this invocation created if it has been interrupted.
+		// Errors are logged by the rollback, the original error is returned.
+		_ = r.rollback.RunIfInterrupted(ctx)
 		return microerror.Mask(err)
 	}
 
@@ -56,7 +116,24 @@ func (r *runner) Run(cmd *cobra.Command, args []string) error {
 }
 
 func (r *runner) run(ctx context.Context, _ *cobra.Command, _ []string) error {
+	// Write trace context to filesystem, so the following commands join the trace
+	{
+		err := r.tracing.WriteTraceparent(ctx, r.flag.Output)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
 	var err error
+
+	if r.flag.PipelineConfig != "" {
+		pipelineConfigs, err := config.LoadPipelineConfigs(r.flag.PipelineConfig)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		key.RegisterPipelineConfigs(pipelineConfigs)
+	}
+
 	provider := r.flag.Provider
 	release, err := r.findLatestRelease(ctx)
 	if err != nil {
@@ -97,7 +174,10 @@ func (r *runner) run(ctx context.Context, _ *cobra.Command, _ []string) error {
 		if release.Labels == nil {
 			release.Labels = map[string]string{}
 		}
-		release.Labels["giantswarm.io/testing"] = "true"
+		release.Labels[key.LabelTesting] = "true"
+
+		// Record the pipeline run owning the release.
+		release.Labels, release.Annotations = r.flag.Metadata.ObjectMeta(release.Labels, release.Annotations)
 	}
 
 	// Create release CR.
@@ -120,16 +200,27 @@ func (r *runner) createRelease(ctx context.Context, release *v1alpha1.Release) e
 		}
 	}
 
+	r.metrics.SetLabels(metrics.Labels{
+		Installation: installation,
+		Provider:     r.flag.Provider,
+		Release:      strings.TrimPrefix(release.Name, "v"),
+	})
+
 	// Create release in the management cluster.
-	kubeconfigPath := key.KubeconfigPath(r.flag.Kubeconfig, installation)
+	var kubeconfigConfig config.KubeconfigConfig
+	{
+		var err error
+		kubeconfigConfig, err = config.LoadKubeconfigConfig(r.flag.Config, installation)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
 
 	// Create REST config for the control plane
 	var restConfig *rest.Config
 	{
 		var err error
-		restConfig, err = clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
-			&clientcmd.ClientConfigLoadingRules{ExplicitPath: kubeconfigPath},
-			&clientcmd.ConfigOverrides{}).ClientConfig()
+		restConfig, err = r.flag.Kubeconfig.RESTConfig(installation, kubeconfigConfig)
 		if err != nil {
 			return microerror.Mask(err)
 		}
@@ -171,10 +262,25 @@ func (r *runner) createRelease(ctx context.Context, release *v1alpha1.Release) e
 	// Create the Release CR
 	r.logger.LogCtx(ctx, "message", "creating release CR")
 	{
-		_, err := k8sClient.G8sClient().ReleaseV1alpha1().Releases().Create(ctx, release, v1.CreateOptions{})
+		stepCtx, end := tracing.Start(ctx, metrics.StepReleaseCreation)
+		done := r.metrics.Step(metrics.StepReleaseCreation)
+		_, err := k8sClient.G8sClient().ReleaseV1alpha1().Releases().Create(stepCtx, release, v1.CreateOptions{})
+		done(err)
+		end(err)
 		if err != nil {
 			return microerror.Mask(err)
 		}
+
+		releaseName := release.Name
+		r.rollback.Add(fmt.Sprintf("delete release CR %s", releaseName), func(ctx context.Context) error {
+			err := k8sClient.G8sClient().ReleaseV1alpha1().Releases().Delete(ctx, releaseName, v1.DeleteOptions{})
+			if apierrors.IsNotFound(err) {
+				return nil
+			} else if err != nil {
+				return microerror.Mask(err)
+			}
+			return nil
+		})
 	}
 	r.logger.LogCtx(ctx, "message", "created release CR")
 
@@ -203,10 +309,15 @@ func (r *runner) createRelease(ctx context.Context, release *v1alpha1.Release) e
 
 			return nil
 		}
-		// Retry basically forever, the tekton task will determine maximum runtime.
-		b := backoff.NewMaxRetries(^uint64(0), 20*time.Second)
-
-		err := backoff.Retry(o, b)
+		// Retry until the pipeline's release timeout, or basically forever if there is none.
+		_, pipelineConfig := key.GetPipelineConfigByName(r.flag.Pipeline)
+		b := key.WaitBackOff(ctx, pipelineConfig.Timeouts.Release.Duration, 20*time.Second)
+
+		stepCtx, end := tracing.Start(ctx, metrics.StepReleaseReady)
+		done := r.metrics.Step(metrics.StepReleaseReady)
+		err := backoff.RetryNotify(o, b, tracing.Notify(stepCtx))
+		done(err)
+		end(err)
 		if err != nil {
 			return microerror.Mask(err)
 		}
@@ -217,55 +328,29 @@ func (r *runner) createRelease(ctx context.Context, release *v1alpha1.Release) e
 }
 
 func (r *runner) findLatestRelease(ctx context.Context) (*v1alpha1.Release, error) {
-	var release v1alpha1.Release
-	var releasePath string
 	provider := r.flag.Provider
 	r.logger.LogCtx(ctx, "message", fmt.Sprintf("determining latest release for provider %s", provider))
-	{
-		entries, err := os.ReadDir(filepath.Join(r.flag.ReleasesPath, provider))
-		if err != nil {
-			return nil, microerror.Mask(err)
-		}
-
-		var latest *semver.Version
 
-		for _, f := range entries {
-			if !f.IsDir() || f.Name() == "." || f.Name() == ".." || f.Name() == "archived" {
-				continue
-			}
-
-			// Try to parse version.
-			version, err := semver.NewVersion(strings.TrimPrefix(f.Name(), "v"))
-			if err != nil {
-				r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("Unable to parse semver version from %q", f.Name()))
-				continue
-			}
-
-			if latest == nil || version.GreaterThan(latest) {
-				latest = version
-			}
-		}
-
-		if latest == nil {
-			return nil, microerror.Maskf(releaseNotFoundError, "Can't find a valid release")
-		}
-
-		r.logger.LogCtx(ctx, "message", fmt.Sprintf("latest %s release is %s", provider, latest.String()))
-
-		releasePath = filepath.Join(r.flag.ReleasesPath, provider, fmt.Sprintf("v%s", latest.String()), "release.yaml")
+	versions, skipped, err := releases.Versions(r.flag.ReleasesPath, provider)
+	if err != nil {
+		return nil, microerror.Mask(err)
 	}
-
-	releaseYAML, err := os.ReadFile(releasePath)
+	for _, name := range skipped {
+		r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("Unable to parse semver version from %q", name))
+	}
+	latest, err := releases.Latest(versions)
 	if err != nil {
 		return nil, microerror.Mask(err)
 	}
 
-	err = yaml.Unmarshal(releaseYAML, &release)
+	r.logger.LogCtx(ctx, "message", fmt.Sprintf("latest %s release is %s", provider, latest.String()))
+
+	release, err := releases.Load(r.flag.ReleasesPath, provider, latest)
 	if err != nil {
 		return nil, microerror.Mask(err)
 	}
 
-	return &release, nil
+	return release, nil
 }
 
 func generateReleaseName(name string) string {
@@ -285,7 +370,7 @@ func (r *runner) updateRelease(ctx context.Context, release *v1alpha1.Release) e
 	var headSHA string
 	var providerOperatorVersion string
 	{
-		headSHA, err = git.HeadSHA(r.flag.OperatorPath)
+		headSHA, err = git.HeadSHA(ctx, r.flag.OperatorPath)
 		if err != nil {
 			return microerror.Mask(err)
 		}
@@ -1,13 +1,25 @@
 package cmd
 
-import "github.com/spf13/cobra"
+import (
+	"github.com/giantswarm/microerror"
+	"github.com/spf13/cobra"
+
+	"github.com/giantswarm/standup/pkg/logging"
+)
 
 type flag struct {
+	Logging logging.Flags
 }
 
 func (f *flag) Init(cmd *cobra.Command) {
+	f.Logging.Init(cmd)
 }
 
 func (f *flag) Validate() error {
+	err := f.Logging.Validate()
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
 	return nil
 }
@@ -0,0 +1,60 @@
+package list
+
+import (
+	"github.com/giantswarm/microerror"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/giantswarm/standup/pkg/key"
+	"github.com/giantswarm/standup/pkg/kubeconfig"
+)
+
+const (
+	flagConfig               = "config"
+	flagInstallation         = "installation"
+	flagOrganizationSelector = "organization-selector"
+	flagOrphans              = "orphans"
+	flagOutput               = "output"
+)
+
+const (
+	outputJSON  = "json"
+	outputTable = "table"
+)
+
+type flag struct {
+	Config               string
+	Installation         string
+	OrganizationSelector string
+	Orphans              bool
+	Output               string
+
+	Kubeconfig kubeconfig.Flags
+}
+
+func (f *flag) Init(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&f.Config, flagConfig, "g", "", `The path to the file containing API endpoints and tokens for each provider.`)
+	cmd.Flags().StringVarP(&f.Installation, flagInstallation, "i", "", `The management cluster to list ('aws', 'azure', 'kvm', 'gcp', 'openstack' or 'aws-china').`)
+	cmd.Flags().StringVar(&f.OrganizationSelector, flagOrganizationSelector, key.DefaultOrganizationSelector, `The label selector of the Organization CRs owning test clusters, in addition to Organization CRs labelled for testing.`)
+	cmd.Flags().BoolVar(&f.Orphans, flagOrphans, false, `List only orphans: test releases without clusters and clusters whose release was deleted.`)
+	cmd.Flags().StringVarP(&f.Output, flagOutput, "o", outputTable, `The output format ('table' or 'json').`)
+
+	f.Kubeconfig.Init(cmd)
+}
+
+func (f *flag) Validate() error {
+	if f.Config == "" {
+		return microerror.Maskf(invalidFlagError, "--%s is required", flagConfig)
+	}
+	if f.Installation == "" {
+		return microerror.Maskf(invalidFlagError, "--%s is required", flagInstallation)
+	}
+	if _, err := labels.Parse(f.OrganizationSelector); err != nil {
+		return microerror.Maskf(invalidFlagError, "--%s must be a label selector: %s", flagOrganizationSelector, err)
+	}
+	if f.Output != outputTable && f.Output != outputJSON {
+		return microerror.Maskf(invalidFlagError, "--%s must be %#q or %#q", flagOutput, outputTable, outputJSON)
+	}
+
+	return nil
+}
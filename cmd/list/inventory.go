@@ -0,0 +1,228 @@
+package list
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/giantswarm/apiextensions/v2/pkg/apis/release/v1alpha1"
+	"github.com/giantswarm/microerror"
+	"k8s.io/apimachinery/pkg/util/duration"
+
+	"github.com/giantswarm/standup/pkg/gsclient"
+	"github.com/giantswarm/standup/pkg/key"
+	"github.com/giantswarm/standup/pkg/pipelinerun"
+)
+
+const (
+	orphanNoClusters     = "no clusters"
+	orphanReleaseDeleted = "release deleted"
+)
+
+// Inventory is the set of live test clusters and releases of an installation.
+type Inventory struct {
+	Installation string    `json:"installation"`
+	Clusters     []Cluster `json:"clusters"`
+	Releases     []Release `json:"releases"`
+}
+
+// Cluster is a test cluster as listed by the GS API.
+type Cluster struct {
+	ID            string               `json:"id"`
+	Name          string               `json:"name"`
+	Organization  string               `json:"organization"`
+	Release       string               `json:"release"`
+	Created       time.Time            `json:"created"`
+	Deleting      bool                 `json:"deleting,omitempty"`
+	Pipeline      pipelinerun.Metadata `json:"pipeline"`
+	ReleaseExists bool                 `json:"releaseExists"`
+	Orphan        string               `json:"orphan,omitempty"`
+}
+
+// Release is a Release CR labelled for testing.
+type Release struct {
+	Name     string               `json:"name"`
+	Created  time.Time            `json:"created"`
+	Pipeline pipelinerun.Metadata `json:"pipeline"`
+	Clusters []string             `json:"clusters"`
+	Orphan   string               `json:"orphan,omitempty"`
+}
+
+// newInventory joins the clusters with the Release CRs of the installation.
+// Clusters are test clusters when owned by one of the test organizations or
+// running a test release. Every Release CR is needed to tell whether the
+// release of a cluster still exists, but only test releases are listed.
+func newInventory(installation string, clusters []gsclient.ClusterEntry, releases []v1alpha1.Release, testOrganizations map[string]bool) Inventory {
+	inventory := Inventory{
+		Installation: installation,
+		Clusters:     []Cluster{},
+		Releases:     []Release{},
+	}
+
+	existing := map[string]bool{}
+	testReleases := map[string]int{}
+	for _, r := range releases {
+		existing[r.Name] = true
+		if r.Labels[key.LabelTesting] != "true" {
+			continue
+		}
+		testReleases[r.Name] = len(inventory.Releases)
+		inventory.Releases = append(inventory.Releases, Release{
+			Name:     r.Name,
+			Created:  r.CreationTimestamp.Time,
+			Pipeline: pipelinerun.FromAnnotations(r.Annotations),
+			Clusters: []string{},
+		})
+	}
+
+	for _, c := range clusters {
+		// The GS API omits the leading v of release names.
+		release := "v" + strings.TrimPrefix(c.ReleaseVersion, "v")
+
+		i, onTestRelease := testReleases[release]
+		if !onTestRelease && !testOrganizations[c.Owner] {
+			continue
+		}
+		if onTestRelease {
+			inventory.Releases[i].Clusters = append(inventory.Releases[i].Clusters, c.ID)
+		}
+
+		cluster := Cluster{
+			ID:            c.ID,
+			Name:          c.Name,
+			Organization:  c.Owner,
+			Release:       release,
+			Created:       c.CreateDate,
+			Deleting:      c.DeleteDate != nil,
+			Pipeline:      clusterPipeline(c),
+			ReleaseExists: existing[release],
+		}
+		if !cluster.ReleaseExists {
+			cluster.Orphan = orphanReleaseDeleted
+		}
+		inventory.Clusters = append(inventory.Clusters, cluster)
+	}
+
+	for i := range inventory.Releases {
+		if len(inventory.Releases[i].Clusters) == 0 {
+			inventory.Releases[i].Orphan = orphanNoClusters
+		}
+	}
+
+	sort.Slice(inventory.Clusters, func(i, j int) bool {
+		return inventory.Clusters[i].Created.Before(inventory.Clusters[j].Created)
+	})
+	sort.Slice(inventory.Releases, func(i, j int) bool {
+		return inventory.Releases[i].Created.Before(inventory.Releases[j].Created)
+	})
+
+	return inventory
+}
+
+// clusterPipeline returns the pipeline run a cluster was created by, as far
+// as it can be recovered. This is best-effort: the labels of the cluster are
+// sanitized and only exist when it supports labelling, while the description
+// in its name is truncated to the maximum name length and is then ignored.
+// Fields missing from the labels are taken from the description.
+func clusterPipeline(c gsclient.ClusterEntry) pipelinerun.Metadata {
+	m := pipelinerun.FromLabels(c.Labels)
+	description := pipelinerun.ParseDescription(c.Name)
+	if m.CommitSHA == "" {
+		m.CommitSHA = description.CommitSHA
+	}
+	if m.PipelineRun == "" {
+		m.PipelineRun = description.PipelineRun
+	}
+	if m.Requester == "" {
+		m.Requester = description.Requester
+	}
+
+	return m
+}
+
+// orphans returns the inventory reduced to its orphaned clusters and releases.
+func (i Inventory) orphans() Inventory {
+	result := Inventory{
+		Installation: i.Installation,
+		Clusters:     []Cluster{},
+		Releases:     []Release{},
+	}
+	for _, c := range i.Clusters {
+		if c.Orphan != "" {
+			result.Clusters = append(result.Clusters, c)
+		}
+	}
+	for _, r := range i.Releases {
+		if r.Orphan != "" {
+			result.Releases = append(result.Releases, r)
+		}
+	}
+
+	return result
+}
+
+func (i Inventory) write(w io.Writer, output string, now time.Time) error {
+	if output == outputJSON {
+		data, err := json.MarshalIndent(i, "", "  ")
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		_, err = fmt.Fprintf(w, "%s\n", data)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "CLUSTER\tRELEASE\tAGE\tORGANIZATION\tPIPELINE RUN\tREQUESTER\tRELEASE EXISTS\tORPHAN\n")
+	for _, c := range i.Clusters {
+		id := c.ID
+		if c.Deleting {
+			id += " (deleting)"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", id, c.Release, age(c.Created, now), c.Organization, orNone(c.Pipeline.PipelineRun), orNone(c.Pipeline.Requester), yesNo(c.ReleaseExists), orNone(c.Orphan))
+	}
+
+	fmt.Fprintf(tw, "\nRELEASE\tAGE\tPIPELINE RUN\tREQUESTER\tCLUSTERS\tORPHAN\n")
+	for _, r := range i.Releases {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", r.Name, age(r.Created, now), orNone(r.Pipeline.PipelineRun), orNone(r.Pipeline.Requester), orNone(strings.Join(r.Clusters, ",")), orNone(r.Orphan))
+	}
+
+	err := tw.Flush()
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+func age(created, now time.Time) string {
+	if created.IsZero() {
+		return "-"
+	}
+
+	return duration.HumanDuration(now.Sub(created))
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "-"
+	}
+
+	return s
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+
+	return "no"
+}
@@ -0,0 +1,143 @@
+package list
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/giantswarm/apiextensions/v2/pkg/apis/release/v1alpha1"
+	"github.com/google/go-cmp/cmp"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/giantswarm/standup/pkg/gsclient"
+	"github.com/giantswarm/standup/pkg/key"
+	"github.com/giantswarm/standup/pkg/pipelinerun"
+)
+
+func Test_newInventory(t *testing.T) {
+	created := time.Date(2021, 3, 19, 12, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name              string
+		clusters          []gsclient.ClusterEntry
+		releases          []v1alpha1.Release
+		testOrganizations map[string]bool
+		expected          Inventory
+	}{
+		{
+			name:     "case 0: nothing to list",
+			expected: Inventory{Installation: "aws", Clusters: []Cluster{}, Releases: []Release{}},
+		},
+		{
+			name: "case 1: cluster on a test release",
+			clusters: []gsclient.ClusterEntry{
+				{ID: "abc12", Name: "13.0.0-1 (run aws-release-test-abcde, by jane)", Owner: "customer", ReleaseVersion: "13.0.0-1", CreateDate: created},
+			},
+			releases: []v1alpha1.Release{
+				newRelease("v13.0.0-1", true),
+			},
+			expected: Inventory{
+				Installation: "aws",
+				Clusters: []Cluster{
+					{
+						ID:            "abc12",
+						Name:          "13.0.0-1 (run aws-release-test-abcde, by jane)",
+						Organization:  "customer",
+						Release:       "v13.0.0-1",
+						Created:       created,
+						Pipeline:      pipelinerun.Metadata{PipelineRun: "aws-release-test-abcde", Requester: "jane"},
+						ReleaseExists: true,
+					},
+				},
+				Releases: []Release{
+					{Name: "v13.0.0-1", Clusters: []string{"abc12"}},
+				},
+			},
+		},
+		{
+			name: "case 2: orphans and clusters which are not test clusters",
+			clusters: []gsclient.ClusterEntry{
+				{ID: "abc12", Owner: "conformance-testing", ReleaseVersion: "13.0.0-1"},
+				{ID: "def34", Owner: "customer", ReleaseVersion: "13.0.0"},
+				{ID: "ghi56", Owner: "customer", ReleaseVersion: "12.0.0"},
+			},
+			releases: []v1alpha1.Release{
+				newRelease("v13.0.0", false),
+				newRelease("v13.0.0-2", true),
+			},
+			testOrganizations: map[string]bool{"conformance-testing": true},
+			expected: Inventory{
+				Installation: "aws",
+				Clusters: []Cluster{
+					{
+						ID:           "abc12",
+						Organization: "conformance-testing",
+						Release:      "v13.0.0-1",
+						Orphan:       orphanReleaseDeleted,
+					},
+				},
+				Releases: []Release{
+					{Name: "v13.0.0-2", Clusters: []string{}, Orphan: orphanNoClusters},
+				},
+			},
+		},
+		{
+			name: "case 3: pipeline from labels of a cluster with a truncated name",
+			clusters: []gsclient.ClusterEntry{
+				{
+					ID:             "abc12",
+					Name:           "13.0.0-1 (run aws-release-test-abcde, commit 0123456, by ja",
+					Owner:          "conformance-testing",
+					ReleaseVersion: "13.0.0-1",
+					Labels: map[string]string{
+						pipelinerun.LabelCommitSHA:   "0123456789abcdef0123456789abcdef01234567",
+						pipelinerun.LabelPipelineRun: "aws-release-test-abcde",
+					},
+				},
+			},
+			releases: []v1alpha1.Release{
+				newRelease("v13.0.0-1", true),
+			},
+			testOrganizations: map[string]bool{"conformance-testing": true},
+			expected: Inventory{
+				Installation: "aws",
+				Clusters: []Cluster{
+					{
+						ID:            "abc12",
+						Name:          "13.0.0-1 (run aws-release-test-abcde, commit 0123456, by ja",
+						Organization:  "conformance-testing",
+						Release:       "v13.0.0-1",
+						Pipeline:      pipelinerun.Metadata{CommitSHA: "0123456789abcdef0123456789abcdef01234567", PipelineRun: "aws-release-test-abcde"},
+						ReleaseExists: true,
+					},
+				},
+				Releases: []Release{
+					{Name: "v13.0.0-1", Clusters: []string{"abc12"}},
+				},
+			},
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			result := newInventory("aws", tc.clusters, tc.releases, tc.testOrganizations)
+
+			if !cmp.Equal(result, tc.expected) {
+				t.Fatalf("\n\n%s\n", cmp.Diff(tc.expected, result))
+			}
+		})
+	}
+}
+
+func newRelease(name string, testing bool) v1alpha1.Release {
+	release := v1alpha1.Release{
+		ObjectMeta: v1.ObjectMeta{Name: name},
+	}
+	if testing {
+		release.Labels = map[string]string{key.LabelTesting: "true"}
+	}
+
+	return release
+}
@@ -0,0 +1,132 @@
+package list
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/giantswarm/k8sclient/v4/pkg/k8sclient"
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	"github.com/spf13/cobra"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+
+	"github.com/giantswarm/standup/pkg/config"
+	"github.com/giantswarm/standup/pkg/gsclient"
+	"github.com/giantswarm/standup/pkg/key"
+)
+
+type runner struct {
+	flag   *flag
+	logger micrologger.Logger
+	stdout io.Writer
+	stderr io.Writer
+}
+
+func (r *runner) Run(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+
+	err := r.flag.Validate()
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	err = r.run(ctx, cmd, args)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+func (r *runner) run(ctx context.Context, _ *cobra.Command, _ []string) error {
+	var providerConfig *config.ProviderConfig
+	{
+		var err error
+		providerConfig, err = config.LoadProviderConfig(r.flag.Config, r.flag.Installation)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	// Create a GS API client for listing tenant clusters
+	var gsClient *gsclient.Client
+	{
+		c := gsclient.Config{
+			Logger: r.logger,
+
+			Endpoint: providerConfig.Endpoint,
+			Username: providerConfig.Username,
+			Password: providerConfig.Password,
+			Token:    providerConfig.Token,
+		}
+
+		var err error
+		gsClient, err = gsclient.New(c)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	// Create REST config for the control plane
+	var restConfig *rest.Config
+	{
+		var err error
+		restConfig, err = r.flag.Kubeconfig.RESTConfig(r.flag.Installation, providerConfig.Kubeconfig)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	// Create k8s clients for the control plane
+	var k8sClient k8sclient.Interface
+	{
+		var err error
+		k8sClient, err = k8sclient.NewClients(k8sclient.ClientsConfig{
+			Logger:     r.logger,
+			RestConfig: restConfig,
+		})
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	// Test clusters are owned by the organizations matching the selector or
+	// by ephemeral organizations, which are labelled for testing.
+	testOrganizations := map[string]bool{}
+	for _, selector := range []string{r.flag.OrganizationSelector, key.LabelTesting + "=true"} {
+		organizations, err := k8sClient.G8sClient().SecurityV1alpha1().Organizations().List(ctx, v1.ListOptions{
+			LabelSelector: selector,
+		})
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		for _, o := range organizations.Items {
+			testOrganizations[o.Name] = true
+		}
+	}
+
+	// All releases are listed to tell whether the release of a cluster exists.
+	releases, err := k8sClient.G8sClient().ReleaseV1alpha1().Releases().List(ctx, v1.ListOptions{})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	clusters, err := gsClient.ListClusters(ctx)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	inventory := newInventory(r.flag.Installation, clusters, releases.Items, testOrganizations)
+	if r.flag.Orphans {
+		inventory = inventory.orphans()
+	}
+
+	err = inventory.write(r.stdout, r.flag.Output, time.Now())
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
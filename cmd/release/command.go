@@ -0,0 +1,90 @@
+package release
+
+import (
+	"io"
+	"os"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	"github.com/spf13/cobra"
+
+	"github.com/giantswarm/standup/cmd/release/diff"
+	"github.com/giantswarm/standup/cmd/release/notes"
+)
+
+const (
+	name        = "release"
+	description = "Provides commands for inspecting releases in the releases repo."
+)
+
+type Config struct {
+	Logger micrologger.Logger
+	Stderr io.Writer
+	Stdout io.Writer
+}
+
+func New(config Config) (*cobra.Command, error) {
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+	if config.Stderr == nil {
+		config.Stderr = os.Stderr
+	}
+	if config.Stdout == nil {
+		config.Stdout = os.Stdout
+	}
+
+	var err error
+
+	var diffCmd *cobra.Command
+	{
+		c := diff.Config{
+			Logger: config.Logger,
+			Stderr: config.Stderr,
+			Stdout: config.Stdout,
+		}
+
+		diffCmd, err = diff.New(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	var notesCmd *cobra.Command
+	{
+		c := notes.Config{
+			Logger: config.Logger,
+			Stderr: config.Stderr,
+			Stdout: config.Stdout,
+		}
+
+		notesCmd, err = notes.New(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	f := &flag{}
+
+	r := &runner{
+		flag:   f,
+		logger: config.Logger,
+		stderr: config.Stderr,
+		stdout: config.Stdout,
+	}
+
+	c := &cobra.Command{
+		Use:          name,
+		Short:        description,
+		Long:         description,
+		RunE:         r.Run,
+		SilenceUsage: true,
+	}
+
+	f.Init(c)
+
+	c.AddCommand(diffCmd)
+	c.AddCommand(notesCmd)
+
+	return c, nil
+}
@@ -0,0 +1,60 @@
+package notes
+
+import (
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/giantswarm/microerror"
+	"github.com/spf13/cobra"
+)
+
+const (
+	flagOutput   = "output"
+	flagPrevious = "previous"
+	flagProvider = "provider"
+	flagRelease  = "release"
+	flagReleases = "releases"
+)
+
+const (
+	outputJSON     = "json"
+	outputMarkdown = "markdown"
+)
+
+type flag struct {
+	Output   string
+	Previous string
+	Provider string
+	Release  string
+	Releases string
+}
+
+func (f *flag) Init(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&f.Output, flagOutput, "o", outputMarkdown, `The output format ('markdown' or 'json').`)
+	cmd.Flags().StringVar(&f.Previous, flagPrevious, "", `The release to compare to. Defaults to the greatest release of the provider before --release.`)
+	cmd.Flags().StringVar(&f.Provider, flagProvider, "", `The provider of the release, whose directory in the releases repo holds it.`)
+	cmd.Flags().StringVarP(&f.Release, flagRelease, "r", "", `The semantic version of the release to write notes for.`)
+	cmd.Flags().StringVarP(&f.Releases, flagReleases, "s", "", `The path of the releases repo on the local filesystem.`)
+}
+
+func (f *flag) Validate() error {
+	if f.Output != outputMarkdown && f.Output != outputJSON {
+		return microerror.Maskf(invalidFlagError, "--%s must be %#q or %#q", flagOutput, outputMarkdown, outputJSON)
+	}
+	if f.Previous != "" {
+		if _, err := semver.NewVersion(strings.TrimPrefix(f.Previous, "v")); err != nil {
+			return microerror.Maskf(invalidFlagError, "--%s must be a valid semantic version", flagPrevious)
+		}
+	}
+	if f.Provider == "" {
+		return microerror.Maskf(invalidFlagError, "--%s is required", flagProvider)
+	}
+	if _, err := semver.NewVersion(strings.TrimPrefix(f.Release, "v")); err != nil {
+		return microerror.Maskf(invalidFlagError, "--%s must be a valid semantic version", flagRelease)
+	}
+	if f.Releases == "" {
+		return microerror.Maskf(invalidFlagError, "--%s is required", flagReleases)
+	}
+
+	return nil
+}
@@ -0,0 +1,98 @@
+package notes
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	"github.com/spf13/cobra"
+
+	"github.com/giantswarm/standup/pkg/releases"
+)
+
+type runner struct {
+	flag   *flag
+	logger micrologger.Logger
+	stdout io.Writer
+	stderr io.Writer
+}
+
+func (r *runner) Run(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+
+	err := r.flag.Validate()
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	err = r.run(ctx, cmd, args)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+func (r *runner) run(ctx context.Context, _ *cobra.Command, _ []string) error {
+	version, err := semver.NewVersion(strings.TrimPrefix(r.flag.Release, "v"))
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	release, err := releases.Load(r.flag.Releases, r.flag.Provider, version)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	var releaseDiff releases.Diff
+	if r.flag.Previous != "" {
+		previousVersion, err := semver.NewVersion(strings.TrimPrefix(r.flag.Previous, "v"))
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		previous, err := releases.Load(r.flag.Releases, r.flag.Provider, previousVersion)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		releaseDiff = releases.Compare(previous, release)
+	} else {
+		var skipped []string
+		releaseDiff, skipped, err = releases.CompareToPrevious(r.flag.Releases, r.flag.Provider, release)
+		for _, name := range skipped {
+			r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("Unable to parse semver version from %q", name))
+		}
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	reqs, err := releases.LoadRequests(r.flag.Releases, r.flag.Provider)
+	if os.IsNotExist(microerror.Cause(err)) {
+		r.logger.LogCtx(ctx, "level", "warning", "message", fmt.Sprintf("no requests.yaml for provider %s", r.flag.Provider))
+	} else if err != nil {
+		return microerror.Mask(err)
+	}
+
+	notes, err := releases.NewNotes(releaseDiff, release, reqs)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	for _, u := range notes.Unfulfilled {
+		r.logger.LogCtx(ctx, "level", "warning", "message", fmt.Sprintf("request %s %s is not fulfilled by release %s", u.Name, u.Version, notes.Release))
+	}
+
+	if r.flag.Output == outputJSON {
+		err = notes.WriteJSON(r.stdout)
+	} else {
+		err = notes.WriteMarkdown(r.stdout)
+	}
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
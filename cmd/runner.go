@@ -5,19 +5,37 @@ import (
 	"io"
 
 	"github.com/giantswarm/microerror"
-	"github.com/giantswarm/micrologger"
 	"github.com/spf13/cobra"
+
+	"github.com/giantswarm/standup/pkg/logging"
+	"github.com/giantswarm/standup/pkg/tracing"
 )
 
 type runner struct {
 	flag   *flag
-	logger micrologger.Logger
+	logger *logging.Logger
 	stdout io.Writer
 	stderr io.Writer
 }
 
+// PersistentPreRun configures logging for all commands once flags have been
+// parsed.
+func (r *runner) PersistentPreRun(cmd *cobra.Command, args []string) error {
+	err := r.flag.Validate()
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	r.logger.Configure(r.flag.Logging.Format, r.flag.Logging.Level)
+	if r.flag.Logging.Format == logging.FormatText {
+		tracing.RegisterSpanProcessor(r.logger.SpanProcessor())
+	}
+
+	return nil
+}
+
 func (r *runner) Run(cmd *cobra.Command, args []string) error {
-	ctx := context.Background()
+	ctx := cmd.Context()
 
 	err := r.flag.Validate()
 	if err != nil {
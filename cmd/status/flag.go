@@ -0,0 +1,64 @@
+package status
+
+import (
+	"github.com/giantswarm/microerror"
+	"github.com/spf13/cobra"
+
+	"github.com/giantswarm/standup/pkg/kubeconfig"
+)
+
+const (
+	flagClusterID        = "cluster"
+	flagConfig           = "config"
+	flagCreateKubeconfig = "create-kubeconfig"
+	flagInstallation     = "installation"
+	flagOutput           = "output"
+	flagOutputDir        = "output-dir"
+	flagRelease          = "release"
+)
+
+const (
+	outputJSON = "json"
+	outputText = "text"
+)
+
+type flag struct {
+	ClusterID        string
+	Config           string
+	CreateKubeconfig bool
+	Installation     string
+	Output           string
+	OutputDir        string
+	Release          string
+
+	Kubeconfig kubeconfig.Flags
+}
+
+func (f *flag) Init(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&f.ClusterID, flagClusterID, "c", "", `The ID of the cluster to describe. Defaults to the cluster ID in --output-dir.`)
+	cmd.Flags().StringVarP(&f.Config, flagConfig, "g", "", `The path to the file containing API endpoints and tokens for each provider.`)
+	cmd.Flags().BoolVar(&f.CreateKubeconfig, flagCreateKubeconfig, false, `Create a short-lived admin kubeconfig for the checks of the cluster when there is none in --output-dir. Otherwise these checks are skipped.`)
+	cmd.Flags().StringVarP(&f.Installation, flagInstallation, "i", "", `The management cluster of the cluster ('aws', 'azure', 'kvm', 'gcp', 'openstack' or 'aws-china'). Defaults to the installation in --output-dir.`)
+	cmd.Flags().StringVarP(&f.Output, flagOutput, "o", outputText, `The output format ('text' or 'json').`)
+	cmd.Flags().StringVar(&f.OutputDir, flagOutputDir, "", `The output directory of the create commands, from which the cluster ID, installation, release and kubeconfig of the cluster are read.`)
+	cmd.Flags().StringVarP(&f.Release, flagRelease, "r", "", `The release to describe. Defaults to the release in --output-dir or the release of the cluster.`)
+
+	f.Kubeconfig.Init(cmd)
+}
+
+func (f *flag) Validate() error {
+	if f.ClusterID == "" && f.OutputDir == "" {
+		return microerror.Maskf(invalidFlagError, "--%s or --%s is required", flagClusterID, flagOutputDir)
+	}
+	if f.Config == "" {
+		return microerror.Maskf(invalidFlagError, "--%s is required", flagConfig)
+	}
+	if f.Installation == "" && f.OutputDir == "" {
+		return microerror.Maskf(invalidFlagError, "--%s or --%s is required", flagInstallation, flagOutputDir)
+	}
+	if f.Output != outputText && f.Output != outputJSON {
+		return microerror.Maskf(invalidFlagError, "--%s must be %#q or %#q", flagOutput, outputText, outputJSON)
+	}
+
+	return nil
+}
@@ -0,0 +1,246 @@
+package status
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	applicationv1alpha1 "github.com/giantswarm/apiextensions/v3/pkg/apis/application/v1alpha1"
+	k8sclientv4 "github.com/giantswarm/k8sclient/v4/pkg/k8sclient"
+	"github.com/giantswarm/k8sclient/v5/pkg/k8sclient"
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/giantswarm/standup/pkg/config"
+	"github.com/giantswarm/standup/pkg/gsclient"
+	"github.com/giantswarm/standup/pkg/key"
+	"github.com/giantswarm/standup/pkg/probe"
+)
+
+type runner struct {
+	flag   *flag
+	logger micrologger.Logger
+	stdout io.Writer
+	stderr io.Writer
+}
+
+func (r *runner) Run(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+
+	err := r.flag.Validate()
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	err = r.run(ctx, cmd, args)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+func (r *runner) run(ctx context.Context, _ *cobra.Command, _ []string) error {
+	// Flags take precedence over what the create commands wrote to the output directory.
+	installation, err := r.fromOutputDir(r.flag.Installation, "installation")
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	clusterID, err := r.fromOutputDir(r.flag.ClusterID, "cluster-id")
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	releaseVersion, err := r.fromOutputDir(r.flag.Release, "release-id")
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	if installation == "" {
+		return microerror.Maskf(invalidFlagError, "--%s is required when %s does not contain the installation", flagInstallation, r.flag.OutputDir)
+	}
+	if clusterID == "" {
+		return microerror.Maskf(invalidFlagError, "--%s is required when %s does not contain the cluster ID", flagClusterID, r.flag.OutputDir)
+	}
+
+	status := &Status{
+		Installation: installation,
+		ClusterID:    clusterID,
+	}
+
+	var providerConfig *config.ProviderConfig
+	{
+		var err error
+		providerConfig, err = config.LoadProviderConfig(r.flag.Config, installation)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	// Create a GS API client for managing tenant clusters
+	var gsClient *gsclient.Client
+	{
+		c := gsclient.Config{
+			Logger: r.logger,
+
+			Endpoint: providerConfig.Endpoint,
+			Username: providerConfig.Username,
+			Password: providerConfig.Password,
+			Token:    providerConfig.Token,
+		}
+
+		var err error
+		gsClient, err = gsclient.New(c)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	// Create REST config for the control plane
+	var restConfig *rest.Config
+	{
+		var err error
+		restConfig, err = r.flag.Kubeconfig.RESTConfig(installation, providerConfig.Kubeconfig)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	// Create k8s clients for the control plane
+	var k8sClient k8sclientv4.Interface
+	{
+		var err error
+		k8sClient, err = k8sclientv4.NewClients(k8sclientv4.ClientsConfig{
+			Logger:     r.logger,
+			RestConfig: restConfig,
+		})
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	var clusterExists bool
+	{
+		cluster, err := gsClient.GetCluster(ctx, clusterID)
+		if gsclient.IsClusterNotFoundError(err) {
+			status.add(checkCluster, err)
+		} else if err != nil {
+			return microerror.Mask(err)
+		} else {
+			clusterExists = true
+			status.Organization = cluster.Owner
+			if releaseVersion == "" {
+				// Have to add back the leading v in the release name
+				releaseVersion = fmt.Sprintf("v%s", cluster.ReleaseVersion)
+			}
+			status.add(checkCluster, nil)
+		}
+	}
+
+	status.ReleaseVersion = releaseVersion
+	if releaseVersion != "" {
+		err := probe.Release(ctx, k8sClient.G8sClient(), releaseVersion)
+		status.add(checkRelease, err)
+	} else {
+		status.skip(checkRelease, "release of the cluster is unknown")
+	}
+
+	if clusterExists {
+		err := r.probeCluster(ctx, gsClient, status)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	} else {
+		for _, name := range []string{checkAPI, checkNodes, checkCharts} {
+			status.skip(name, "cluster not found")
+		}
+	}
+
+	err = status.write(r.stdout, r.flag.Output)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// probeCluster checks the API, nodes and charts of the workload cluster once.
+func (r *runner) probeCluster(ctx context.Context, gsClient *gsclient.Client, status *Status) error {
+	var restConfig *rest.Config
+	{
+		kubeconfigPath := filepath.Join(r.flag.OutputDir, "kubeconfig")
+		if _, err := os.Stat(kubeconfigPath); r.flag.OutputDir != "" && err == nil {
+			restConfig, err = clientcmd.BuildConfigFromFlags("", kubeconfigPath)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+		} else if !r.flag.CreateKubeconfig {
+			for _, name := range []string{checkAPI, checkNodes, checkCharts} {
+				status.skip(name, "no kubeconfig")
+			}
+			return nil
+		} else {
+			// Without the kubeconfig of the pipeline, a short-lived one is created on request.
+			restConfig, err = gsClient.CreateRESTConfig(ctx, status.ClusterID, gsclient.KubeconfigOptions{
+				CertificateOrganizations: []string{key.DefaultCertificateOrganization},
+				TTL:                      time.Hour,
+			})
+			if err != nil {
+				status.add(checkAPI, err)
+				status.skip(checkNodes, "no kubeconfig")
+				status.skip(checkCharts, "no kubeconfig")
+				return nil
+			}
+		}
+		restConfig.Timeout = time.Second * 10
+	}
+
+	c, err := k8sclient.NewClients(k8sclient.ClientsConfig{
+		Logger: r.logger,
+		SchemeBuilder: k8sclient.SchemeBuilder{
+			applicationv1alpha1.AddToScheme,
+		},
+		RestConfig: restConfig,
+	})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	err = probe.API(ctx, c.K8sClient())
+	status.add(checkAPI, err)
+	if err != nil {
+		status.skip(checkNodes, "API not reachable")
+		status.skip(checkCharts, "API not reachable")
+		return nil
+	}
+
+	err = probe.Nodes(ctx, c.K8sClient(), 0)
+	status.add(checkNodes, err)
+
+	status.Charts, err = probe.Charts(ctx, c.CtrlClient())
+	status.add(checkCharts, err)
+
+	return nil
+}
+
+// fromOutputDir returns value if it is set, or else the content of the given
+// file in the output directory, if any.
+func (r *runner) fromOutputDir(value, file string) (string, error) {
+	if value != "" || r.flag.OutputDir == "" {
+		return value, nil
+	}
+
+	data, err := os.ReadFile(filepath.Join(r.flag.OutputDir, file))
+	if os.IsNotExist(err) {
+		return "", nil
+	} else if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	return strings.TrimSpace(string(data)), nil
+}
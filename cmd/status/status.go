@@ -0,0 +1,107 @@
+package status
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/standup/pkg/probe"
+)
+
+const (
+	checkAPI     = "api"
+	checkCharts  = "charts"
+	checkCluster = "cluster"
+	checkNodes   = "nodes"
+	checkRelease = "release"
+)
+
+// Status describes a test cluster and its release at one point in time.
+type Status struct {
+	Installation   string        `json:"installation"`
+	ClusterID      string        `json:"clusterID"`
+	Organization   string        `json:"organization,omitempty"`
+	ReleaseVersion string        `json:"releaseVersion,omitempty"`
+	Checks         []Check       `json:"checks"`
+	Charts         []probe.Chart `json:"charts,omitempty"`
+}
+
+// Check is the outcome of a single probe.
+type Check struct {
+	Name    string `json:"name"`
+	Ready   bool   `json:"ready"`
+	Message string `json:"message,omitempty"`
+}
+
+// add records the outcome of a probe. The error describes why it failed.
+func (s *Status) add(name string, err error) {
+	c := Check{
+		Name:  name,
+		Ready: err == nil,
+	}
+	if err != nil {
+		c.Message = microerror.Pretty(err, false)
+	}
+
+	s.Checks = append(s.Checks, c)
+}
+
+// skip records a probe which could not run.
+func (s *Status) skip(name string, reason string) {
+	s.Checks = append(s.Checks, Check{
+		Name:    name,
+		Message: "Skipped: " + reason,
+	})
+}
+
+func (s *Status) write(w io.Writer, output string) error {
+	if output == outputJSON {
+		data, err := json.MarshalIndent(s, "", "  ")
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		_, err = fmt.Fprintf(w, "%s\n", data)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "Installation:\t%s\n", s.Installation)
+	fmt.Fprintf(tw, "Cluster:\t%s\n", s.ClusterID)
+	if s.Organization != "" {
+		fmt.Fprintf(tw, "Organization:\t%s\n", s.Organization)
+	}
+	if s.ReleaseVersion != "" {
+		fmt.Fprintf(tw, "Release:\t%s\n", s.ReleaseVersion)
+	}
+
+	fmt.Fprintf(tw, "\nCHECK\tREADY\tMESSAGE\n")
+	for _, c := range s.Checks {
+		ready := "no"
+		if c.Ready {
+			ready = "yes"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", c.Name, ready, c.Message)
+	}
+
+	if len(s.Charts) > 0 {
+		fmt.Fprintf(tw, "\nCHART\tSTATUS\n")
+		for _, c := range s.Charts {
+			fmt.Fprintf(tw, "%s\t%s\n", c.Name, c.Status)
+		}
+	}
+
+	err := tw.Flush()
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
@@ -1,10 +1,6 @@
 package wait
 
-import (
-	"regexp"
-
-	"github.com/giantswarm/microerror"
-)
+import "github.com/giantswarm/microerror"
 
 var invalidConfigError = &microerror.Error{
 	Kind: "invalidConfigError",
@@ -23,21 +19,3 @@ var invalidFlagError = &microerror.Error{
 func IsInvalidFlag(err error) bool {
 	return microerror.Cause(err) == invalidFlagError
 }
-
-var notReadyError = &microerror.Error{
-	Kind: "notReadyError",
-}
-
-// IsNotReady asserts notReadyError.
-func IsNotReady(err error) bool {
-	return microerror.Cause(err) == notReadyError
-}
-
-var serverErrorPattern = regexp.MustCompile(`an error on the server .*`)
-
-func IsServerError(err error) bool {
-	if err == nil {
-		return false
-	}
-	return serverErrorPattern.MatchString(err.Error())
-}
@@ -0,0 +1,44 @@
+package wait
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/giantswarm/microerror"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/giantswarm/standup/pkg/key"
+	"github.com/giantswarm/standup/pkg/utils"
+)
+
+// hasFeature determines whether the workload cluster provides the given
+// feature. An override in the pipeline config takes precedence. Otherwise the
+// feature is detected from the deployed charts and, as a fallback, from the
+// services in kube-system.
+func (r *runner) hasFeature(ctx context.Context, k8sClient kubernetes.Interface, pipelineConfig key.PipelineConfig, chartNames []string, feature string) (bool, error) {
+	if enabled, ok := pipelineConfig.Features[feature]; ok {
+		r.logger.LogCtx(ctx, "message", fmt.Sprintf("feature %#q is set to %t by pipeline %#q", feature, enabled, pipelineConfig.Name))
+		return enabled, nil
+	}
+
+	if utils.AppsProvideFeature(chartNames, feature) {
+		r.logger.LogCtx(ctx, "message", fmt.Sprintf("feature %#q is provided by a deployed chart", feature))
+		return true, nil
+	}
+
+	services, err := k8sClient.CoreV1().Services("kube-system").List(ctx, v1.ListOptions{
+		LabelSelector: fmt.Sprintf("app.kubernetes.io/name=%s", feature),
+	})
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+	if len(services.Items) > 0 {
+		r.logger.LogCtx(ctx, "message", fmt.Sprintf("feature %#q is provided by service %#q", feature, services.Items[0].Name))
+		return true, nil
+	}
+
+	r.logger.LogCtx(ctx, "message", fmt.Sprintf("feature %#q is not deployed in the cluster", feature))
+
+	return false, nil
+}
@@ -0,0 +1,100 @@
+package wait
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/giantswarm/micrologger/microloggertest"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/giantswarm/standup/pkg/key"
+	"github.com/giantswarm/standup/pkg/utils"
+)
+
+func Test_hasFeature(t *testing.T) {
+	testCases := []struct {
+		name           string
+		pipelineConfig key.PipelineConfig
+		chartNames     []string
+		objects        []runtime.Object
+		expected       bool
+	}{
+		{
+			name:     "case 0: feature not deployed",
+			expected: false,
+		},
+		{
+			name:       "case 1: feature provided by a chart",
+			chartNames: []string{"cert-exporter", "external-dns-app"},
+			expected:   true,
+		},
+		{
+			name: "case 2: feature provided by a labelled service",
+			objects: []runtime.Object{
+				newService("kube-system", "external-dns", map[string]string{"app.kubernetes.io/name": "external-dns"}),
+			},
+			expected: true,
+		},
+		{
+			name: "case 3: services in other namespaces or with other labels are ignored",
+			objects: []runtime.Object{
+				newService("default", "external-dns", map[string]string{"app.kubernetes.io/name": "external-dns"}),
+				newService("kube-system", "coredns", map[string]string{"app.kubernetes.io/name": "coredns"}),
+			},
+			expected: false,
+		},
+		{
+			name: "case 4: pipeline override enables the feature",
+			pipelineConfig: key.PipelineConfig{
+				Name:     "aws-china",
+				Features: map[string]bool{utils.FeatureExternalDNS: true},
+			},
+			expected: true,
+		},
+		{
+			name: "case 5: pipeline override disables the feature provided by a chart and a service",
+			pipelineConfig: key.PipelineConfig{
+				Name:     "aws-china",
+				Features: map[string]bool{utils.FeatureExternalDNS: false},
+			},
+			chartNames: []string{"external-dns-app"},
+			objects: []runtime.Object{
+				newService("kube-system", "external-dns", map[string]string{"app.kubernetes.io/name": "external-dns"}),
+			},
+			expected: false,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			r := &runner{
+				logger: microloggertest.New(),
+			}
+
+			result, err := r.hasFeature(context.Background(), fake.NewSimpleClientset(tc.objects...), tc.pipelineConfig, tc.chartNames, utils.FeatureExternalDNS)
+			if err != nil {
+				t.Fatalf("error == %#v, want nil", err)
+			}
+
+			if result != tc.expected {
+				t.Fatalf("result == %t, want %t", result, tc.expected)
+			}
+		})
+	}
+}
+
+func newService(namespace, name string, labels map[string]string) *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    labels,
+		},
+	}
+}
@@ -3,25 +3,43 @@ package wait
 import (
 	"github.com/giantswarm/microerror"
 	"github.com/spf13/cobra"
+
+	"github.com/giantswarm/standup/pkg/key"
+	"github.com/giantswarm/standup/pkg/metrics"
+	"github.com/giantswarm/standup/pkg/tracing"
 )
 
 const (
 	flagKubeconfig        = "kubeconfig"
+	flagPipeline          = "pipeline"
+	flagPipelineConfig    = "pipeline-config"
 	flagProvider          = "provider"
+	flagRelease           = "release"
 	flagDesiredNodesCount = "nodes"
 )
 
 type flag struct {
 	Kubeconfig        string
+	Pipeline          string
+	PipelineConfig    string
 	Provider          string
+	Release           string
 	DesiredNodesCount int
+
+	Metrics metrics.Flags
+	Tracing tracing.Flags
 }
 
 func (f *flag) Init(cmd *cobra.Command) {
 	cmd.Flags().StringVarP(&f.Kubeconfig, flagKubeconfig, "k", "", `The path to the kubeconfig for the tenant cluster.`)
 	cmd.Flags().StringVarP(&f.Provider, flagProvider, "p", "", `The provider of the target control plane.`)
-	cmd.Flags().IntVarP(&f.DesiredNodesCount, flagDesiredNodesCount, "", 2, `The number of nodes to wait for.`)
+	cmd.Flags().IntVarP(&f.DesiredNodesCount, flagDesiredNodesCount, "", 2, `The number of nodes to wait for. Defaults to the node count of the pipeline, if configured.`)
+	cmd.Flags().StringVarP(&f.Pipeline, flagPipeline, "t", key.DefaultPipelineName, `The name of the pipeline in which standup is currently running.`)
+	cmd.Flags().StringVar(&f.PipelineConfig, flagPipelineConfig, "", `The path to a YAML file containing pipeline configurations. Defaults to the built-in configurations.`)
+	cmd.Flags().StringVarP(&f.Release, flagRelease, "r", "", `The release of the tenant cluster. Only used to label metrics.`)
 
+	f.Metrics.Init(cmd)
+	f.Tracing.Init(cmd)
 }
 
 func (f *flag) Validate() error {
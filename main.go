@@ -2,32 +2,42 @@ package main
 
 import (
 	"context"
-	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/giantswarm/microerror"
-	"github.com/giantswarm/micrologger"
 	"github.com/spf13/cobra"
 
 	"github.com/giantswarm/standup/cmd"
+	"github.com/giantswarm/standup/pkg/exitcode"
+	"github.com/giantswarm/standup/pkg/logging"
 	"github.com/giantswarm/standup/pkg/project"
 )
 
 func main() {
-	err := mainE(context.Background())
+	// Cancel the root context on SIGINT and SIGTERM, e.g. when a Tekton run is
+	// cancelled, so running steps can stop and roll back.
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+
+	err := mainE(ctx)
+	stop()
 	if err != nil {
-		panic(fmt.Sprintf("%#v\n", err))
+		// Pipelines branch on the exit code, see the exit code table in the
+		// README.
+		os.Exit(exitcode.Render(os.Stderr, err))
 	}
 }
 
 func mainE(ctx context.Context) error {
 	var err error
 
-	var logger micrologger.Logger
+	// The logger is configured by the root command once flags are parsed.
+	var logger *logging.Logger
 	{
-		c := micrologger.Config{}
+		c := logging.Config{}
 
-		logger, err = micrologger.New(c)
+		logger, err = logging.New(c)
 		if err != nil {
 			return microerror.Mask(err)
 		}
@@ -48,10 +58,10 @@ func mainE(ctx context.Context) error {
 		}
 	}
 
-	err = rootCommand.Execute()
+	err = rootCommand.ExecuteContext(ctx)
 	if err != nil {
-		logger.LogCtx(ctx, "level", "error", "message", "failed to execute command", "stack", microerror.JSON(err))
-		os.Exit(1)
+		logger.LogCtx(ctx, "level", "error", "message", "failed to execute command", "exit_code", exitcode.Classify(err).Code, "stack", microerror.JSON(err))
+		return microerror.Mask(err)
 	}
 
 	return nil
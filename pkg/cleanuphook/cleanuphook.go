@@ -0,0 +1,176 @@
+// Package cleanuphook defines the provider-specific waits `cleanup` runs after
+// a cluster is deleted and before its release is deleted, as provider
+// operators may still need the release to delete their objects.
+package cleanuphook
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/giantswarm/microerror"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/giantswarm/standup/pkg/deletion"
+	"github.com/giantswarm/standup/pkg/key"
+)
+
+// Hook waits for the objects of the deleted cluster of some resources to be gone.
+type Hook struct {
+	Name string `json:"name"`
+	// WaitForDeletion are the resources whose objects of the cluster must be gone.
+	WaitForDeletion []Resource `json:"waitForDeletion"`
+}
+
+// Resource selects the objects of a cluster of one resource.
+type Resource struct {
+	Group    string `json:"group,omitempty"`
+	Version  string `json:"version"`
+	Resource string `json:"resource"`
+	// Namespace of the object named after the cluster ID. Without namespace, the objects
+	// labelled with the cluster ID in all namespaces are selected.
+	Namespace string `json:"namespace,omitempty"`
+}
+
+func (r Resource) GroupVersionResource() schema.GroupVersionResource {
+	return schema.GroupVersionResource{Group: r.Group, Version: r.Version, Resource: r.Resource}
+}
+
+// The built-in hooks. Configurations can add hooks with Select.
+var hooks = map[string]Hook{
+	"awscluster": {
+		Name: "awscluster",
+		WaitForDeletion: []Resource{
+			{Group: "infrastructure.giantswarm.io", Version: "v1alpha2", Resource: "awsclusters"},
+		},
+	},
+	"awsconfig": {
+		Name: "awsconfig",
+		WaitForDeletion: []Resource{
+			{Group: "provider.giantswarm.io", Version: "v1alpha1", Resource: "awsconfigs", Namespace: v1.NamespaceDefault},
+		},
+	},
+	"azureconfig": {
+		Name: "azureconfig",
+		WaitForDeletion: []Resource{
+			{Group: "provider.giantswarm.io", Version: "v1alpha1", Resource: "azureconfigs", Namespace: v1.NamespaceDefault},
+		},
+	},
+	// cluster-service does not return deleting KVM clusters, so the cluster may
+	// be gone from the GS API while its KVMConfig still needs the release.
+	"kvmconfig": {
+		Name: "kvmconfig",
+		WaitForDeletion: []Resource{
+			{Group: "provider.giantswarm.io", Version: "v1alpha1", Resource: "kvmconfigs", Namespace: v1.NamespaceDefault},
+		},
+	},
+}
+
+// providerHooks are the hooks run for each provider by default.
+var providerHooks = map[string][]string{
+	"aws":   {"awsconfig", "awscluster"},
+	"azure": {"azureconfig"},
+	"kvm":   {"kvmconfig"},
+}
+
+// Select returns the hooks of the given names out of the built-in and the
+// custom hooks, which replace built-in hooks of the same name. Nil names
+// select the built-in hooks of the provider and all custom hooks, empty
+// names select no hooks.
+func Select(provider string, names []string, custom []Hook) ([]Hook, error) {
+	available := map[string]Hook{}
+	for name, h := range hooks {
+		available[name] = h
+	}
+	for _, h := range custom {
+		err := validate(h)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+		available[h.Name] = h
+	}
+
+	if names == nil {
+		names = append(names, providerHooks[provider]...)
+		for _, h := range custom {
+			if _, ok := hooks[h.Name]; !ok {
+				names = append(names, h.Name)
+			}
+		}
+	}
+
+	var selected []Hook
+	for _, name := range names {
+		h, ok := available[name]
+		if !ok {
+			return nil, microerror.Maskf(invalidConfigError, "unknown cleanup hook %#q", name)
+		}
+		selected = append(selected, h)
+	}
+
+	return selected, nil
+}
+
+// Remaining returns the objects of the given cluster which the hook waits for.
+// Resources which are not installed have no objects remaining.
+func (h Hook) Remaining(ctx context.Context, dynClient dynamic.Interface, clusterID string) ([]deletion.Object, error) {
+	var remaining []deletion.Object
+	for _, r := range h.WaitForDeletion {
+		resource := r.GroupVersionResource()
+
+		if r.Namespace != "" {
+			obj, err := dynClient.Resource(resource).Namespace(r.Namespace).Get(ctx, clusterID, v1.GetOptions{})
+			if apierrors.IsNotFound(err) {
+				continue
+			} else if err != nil {
+				return nil, microerror.Mask(err)
+			}
+			remaining = append(remaining, object(resource, obj.GetKind(), obj.GetNamespace(), obj.GetName(), obj.GetFinalizers()))
+			continue
+		}
+
+		list, err := dynClient.Resource(resource).List(ctx, v1.ListOptions{
+			LabelSelector: fmt.Sprintf("%s=%s", key.LabelCluster, clusterID),
+		})
+		if apierrors.IsNotFound(err) || meta.IsNoMatchError(err) {
+			// The CRD is not installed, e.g. node pool CRDs on older AWS installations.
+			continue
+		} else if err != nil {
+			return nil, microerror.Mask(err)
+		}
+		for _, obj := range list.Items {
+			remaining = append(remaining, object(resource, obj.GetKind(), obj.GetNamespace(), obj.GetName(), obj.GetFinalizers()))
+		}
+	}
+
+	return remaining, nil
+}
+
+func object(resource schema.GroupVersionResource, kind, namespace, name string, finalizers []string) deletion.Object {
+	return deletion.Object{
+		Resource:   resource,
+		Kind:       schema.GroupKind{Group: resource.Group, Kind: kind}.String(),
+		Namespace:  namespace,
+		Name:       name,
+		Finalizers: finalizers,
+	}
+}
+
+func validate(h Hook) error {
+	if h.Name == "" {
+		return microerror.Maskf(invalidConfigError, "cleanup hooks must have a name")
+	}
+	if len(h.WaitForDeletion) == 0 {
+		return microerror.Maskf(invalidConfigError, "cleanup hook %#q must wait for the deletion of at least one resource", h.Name)
+	}
+	for _, r := range h.WaitForDeletion {
+		if r.Version == "" || r.Resource == "" {
+			return microerror.Maskf(invalidConfigError, "resources of cleanup hook %#q must have a version and resource", h.Name)
+		}
+	}
+
+	return nil
+}
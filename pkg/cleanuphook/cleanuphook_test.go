@@ -0,0 +1,154 @@
+package cleanuphook
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/giantswarm/standup/pkg/deletion"
+)
+
+func Test_Select(t *testing.T) {
+	custom := Hook{
+		Name: "machinepools",
+		WaitForDeletion: []Resource{
+			{Group: "exp.cluster.x-k8s.io", Version: "v1alpha3", Resource: "machinepools"},
+		},
+	}
+
+	testCases := []struct {
+		name         string
+		provider     string
+		names        []string
+		custom       []Hook
+		expected     []string
+		errorMatcher func(error) bool
+	}{
+		{
+			name:     "case 0: built-in hooks of the provider",
+			provider: "aws",
+			expected: []string{"awsconfig", "awscluster"},
+		},
+		{
+			name:     "case 1: provider without hooks",
+			provider: "openstack",
+			expected: nil,
+		},
+		{
+			name:     "case 2: custom hooks run in addition to the built-in ones",
+			provider: "kvm",
+			custom:   []Hook{custom},
+			expected: []string{"kvmconfig", "machinepools"},
+		},
+		{
+			name:     "case 3: selected hooks only",
+			provider: "aws",
+			names:    []string{"machinepools"},
+			custom:   []Hook{custom},
+			expected: []string{"machinepools"},
+		},
+		{
+			name:     "case 4: no hooks selected",
+			provider: "kvm",
+			names:    []string{},
+			expected: nil,
+		},
+		{
+			name:         "case 5: unknown hook",
+			provider:     "aws",
+			names:        []string{"gcpconfig"},
+			errorMatcher: IsInvalidConfig,
+		},
+		{
+			name:         "case 6: custom hook without resources",
+			provider:     "aws",
+			custom:       []Hook{{Name: "empty"}},
+			errorMatcher: IsInvalidConfig,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			hooks, err := Select(tc.provider, tc.names, tc.custom)
+
+			switch {
+			case err == nil && tc.errorMatcher == nil:
+				// correct; carry on
+			case err != nil && tc.errorMatcher == nil:
+				t.Fatalf("error == %#v, want nil", err)
+			case err == nil && tc.errorMatcher != nil:
+				t.Fatalf("error == nil, want non-nil")
+			case !tc.errorMatcher(err):
+				t.Fatalf("error == %#v, want matching", err)
+			}
+
+			var names []string
+			for _, h := range hooks {
+				names = append(names, h.Name)
+			}
+			if !cmp.Equal(names, tc.expected) {
+				t.Fatalf("\n\n%s\n", cmp.Diff(tc.expected, names))
+			}
+		})
+	}
+}
+
+func Test_Remaining(t *testing.T) {
+	dynClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(),
+		newObject("provider.giantswarm.io/v1alpha1", "AWSConfig", "default", "abc12", nil),
+		newObject("provider.giantswarm.io/v1alpha1", "AWSConfig", "default", "def34", nil),
+		newObject("infrastructure.giantswarm.io/v1alpha2", "AWSCluster", "org-test", "abc12", map[string]string{"giantswarm.io/cluster": "abc12"}),
+		newObject("infrastructure.giantswarm.io/v1alpha2", "AWSCluster", "org-test", "def34", map[string]string{"giantswarm.io/cluster": "def34"}),
+	)
+
+	var remaining []deletion.Object
+	for _, name := range providerHooks["aws"] {
+		objects, err := hooks[name].Remaining(context.Background(), dynClient, "abc12")
+		if err != nil {
+			t.Fatalf("error == %#v, want nil", err)
+		}
+		remaining = append(remaining, objects...)
+	}
+
+	expected := "1 AWSCluster.infrastructure.giantswarm.io [org-test/abc12]; 1 AWSConfig.provider.giantswarm.io [default/abc12]"
+	result := deletion.Summarize(remaining)
+	if result != expected {
+		t.Fatalf("\n\n%s\n", cmp.Diff(expected, result))
+	}
+}
+
+func Test_Remaining_notInstalled(t *testing.T) {
+	dynClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
+	dynClient.PrependReactor("list", "awsclusters", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewNotFound(schema.GroupResource{Group: "infrastructure.giantswarm.io", Resource: "awsclusters"}, "")
+	})
+
+	objects, err := hooks["awscluster"].Remaining(context.Background(), dynClient, "abc12")
+	if err != nil {
+		t.Fatalf("error == %#v, want nil", err)
+	}
+	if len(objects) != 0 {
+		t.Fatalf("expected no remaining objects, found %s", deletion.Summarize(objects))
+	}
+}
+
+func newObject(apiVersion, kind, namespace, name string, labels map[string]string) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{}
+	u.SetAPIVersion(apiVersion)
+	u.SetKind(kind)
+	u.SetNamespace(namespace)
+	u.SetName(name)
+	u.SetLabels(labels)
+
+	return u
+}
@@ -0,0 +1,78 @@
+// Package clusterspec defines the shape of test clusters beyond the
+// installation defaults, e.g. node pools and a highly available control plane.
+package clusterspec
+
+import (
+	"os"
+
+	"github.com/giantswarm/microerror"
+	"sigs.k8s.io/yaml"
+)
+
+type Spec struct {
+	// ControlPlane configures the master nodes of the cluster.
+	ControlPlane ControlPlane `json:"controlPlane,omitempty"`
+	// Labels are set on the cluster.
+	Labels map[string]string `json:"labels,omitempty"`
+	// NodePools replace the default node pool of the installation.
+	NodePools []NodePool `json:"nodePools,omitempty"`
+}
+
+type ControlPlane struct {
+	HighAvailability bool `json:"highAvailability,omitempty"`
+}
+
+type NodePool struct {
+	Name string `json:"name,omitempty"`
+	// MinSize and MaxSize are the autoscaling limits of the node pool.
+	MinSize int `json:"minSize,omitempty"`
+	MaxSize int `json:"maxSize,omitempty"`
+	// AvailabilityZones pins the node pool to the given zones.
+	AvailabilityZones []string `json:"availabilityZones,omitempty"`
+	// AvailabilityZonesCount spreads the node pool over this many random zones.
+	AvailabilityZonesCount int `json:"availabilityZonesCount,omitempty"`
+	// InstanceType is the AWS EC2 instance type of the nodes.
+	InstanceType string `json:"instanceType,omitempty"`
+	// VMSize is the Azure VM size of the nodes.
+	VMSize string `json:"vmSize,omitempty"`
+}
+
+// Load reads and validates the cluster spec in the given YAML file.
+func Load(path string) (*Spec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	var spec Spec
+	err = yaml.UnmarshalStrict(data, &spec)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	err = spec.Validate()
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return &spec, nil
+}
+
+func (s *Spec) Validate() error {
+	for i, np := range s.NodePools {
+		if np.MinSize < 0 || np.MaxSize < 0 {
+			return microerror.Maskf(invalidSpecError, "node pool %d must not have negative sizes", i)
+		}
+		if np.MaxSize > 0 && np.MinSize > np.MaxSize {
+			return microerror.Maskf(invalidSpecError, "node pool %d has minSize %d greater than maxSize %d", i, np.MinSize, np.MaxSize)
+		}
+		if len(np.AvailabilityZones) > 0 && np.AvailabilityZonesCount > 0 {
+			return microerror.Maskf(invalidSpecError, "node pool %d must not set both availabilityZones and availabilityZonesCount", i)
+		}
+		if np.AvailabilityZonesCount < 0 {
+			return microerror.Maskf(invalidSpecError, "node pool %d must not have a negative availabilityZonesCount", i)
+		}
+	}
+
+	return nil
+}
@@ -4,14 +4,52 @@ import (
 	"os"
 
 	"github.com/giantswarm/microerror"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/yaml"
+
+	"github.com/giantswarm/standup/pkg/cleanuphook"
+	"github.com/giantswarm/standup/pkg/key"
 )
 
 type ProviderConfig struct {
-	Endpoint string `json:"endpoint"`
-	Password string `json:"password"`
-	Token    string `json:"token"`
-	Username string `json:"username"`
+	Capacity   CapacityConfig   `json:"capacity,omitempty"`
+	Cleanup    CleanupConfig    `json:"cleanup,omitempty"`
+	Endpoint   string           `json:"endpoint"`
+	Kubeconfig KubeconfigConfig `json:"kubeconfig,omitempty"`
+	Password   string           `json:"password"`
+	Token      string           `json:"token"`
+	Username   string           `json:"username"`
+}
+
+// CapacityConfig limits the number of test clusters standup runs on an installation at the same time.
+type CapacityConfig struct {
+	// Slots is the maximum number of concurrent test clusters. Zero means unlimited.
+	Slots int `json:"slots,omitempty"`
+	// MaxSlotLifetime is the time after which a slot is reclaimed unless released. `create cluster`
+	// renews its slot while it runs, but the slot of a created cluster is not renewed, so test runs
+	// longer than this give their slot away before cleanup. Defaults to 4h.
+	MaxSlotLifetime v1.Duration `json:"maxSlotLifetime,omitempty"`
+}
+
+// CleanupConfig selects the provider-specific hooks `cleanup` runs after the cluster is deleted.
+type CleanupConfig struct {
+	// Hooks are the names of the hooks to run. Defaults to the built-in hooks of the provider and
+	// all custom hooks. An empty list runs no hooks.
+	Hooks []string `json:"hooks,omitempty"`
+	// CustomHooks define additional hooks or replace built-in hooks of the same name.
+	CustomHooks []cleanuphook.Hook `json:"customHooks,omitempty"`
+}
+
+// KubeconfigConfig selects how standup reaches the management cluster of an installation.
+// Empty values fall back to --kubeconfig, $KUBECONFIG and the in-cluster config, in this order.
+type KubeconfigConfig struct {
+	// Path is a kubeconfig file, or a directory with one kubeconfig per installation named after it.
+	Path string `json:"path,omitempty"`
+	// Context is the kubeconfig context of the management cluster. Defaults to a context named
+	// after the installation, if any, or the current context.
+	Context string `json:"context,omitempty"`
+	// InCluster uses the service account of the pod, for standup running inside the management cluster.
+	InCluster bool `json:"inCluster,omitempty"`
 }
 
 func LoadProviderConfig(path string, provider string) (*ProviderConfig, error) {
@@ -36,6 +74,48 @@ func LoadProviderConfig(path string, provider string) (*ProviderConfig, error) {
 	if providerConfig.Token == "" && (providerConfig.Username == "" || providerConfig.Password == "") {
 		return nil, microerror.Maskf(invalidConfigError, "missing token or username/password for provider %#q", provider)
 	}
+	if providerConfig.Capacity.Slots < 0 {
+		return nil, microerror.Maskf(invalidConfigError, "capacity slots for provider %#q must not be negative", provider)
+	}
+	err = validateKubeconfig(providerConfig.Kubeconfig, provider)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	if providerConfig.Capacity.MaxSlotLifetime.Duration == 0 {
+		providerConfig.Capacity.MaxSlotLifetime.Duration = key.DefaultCapacityMaxSlotLifetime
+	}
 
 	return &providerConfig, nil
 }
+
+// LoadKubeconfigConfig returns the kubeconfig configuration of the given installation. Unlike
+// LoadProviderConfig it does not require API credentials, as commands which only talk to the
+// management cluster do not need them. Installations without configuration get the defaults.
+func LoadKubeconfigConfig(path string, installation string) (KubeconfigConfig, error) {
+	configData, err := os.ReadFile(path)
+	if err != nil {
+		return KubeconfigConfig{}, microerror.Mask(err)
+	}
+
+	providerConfigs := map[string]ProviderConfig{}
+	err = yaml.UnmarshalStrict(configData, &providerConfigs)
+	if err != nil {
+		return KubeconfigConfig{}, microerror.Mask(err)
+	}
+
+	kubeconfig := providerConfigs[installation].Kubeconfig
+	err = validateKubeconfig(kubeconfig, installation)
+	if err != nil {
+		return KubeconfigConfig{}, microerror.Mask(err)
+	}
+
+	return kubeconfig, nil
+}
+
+func validateKubeconfig(kubeconfig KubeconfigConfig, provider string) error {
+	if kubeconfig.InCluster && (kubeconfig.Path != "" || kubeconfig.Context != "") {
+		return microerror.Maskf(invalidConfigError, "kubeconfig for provider %#q must not set path or context together with inCluster", provider)
+	}
+
+	return nil
+}
@@ -7,6 +7,6 @@ var invalidConfigError = &microerror.Error{
 }
 
 // IsInvalidConfig asserts invalidConfigError.
-func IsClusterCreationError(err error) bool {
+func IsInvalidConfig(err error) bool {
 	return microerror.Cause(err) == invalidConfigError
 }
@@ -0,0 +1,46 @@
+package config
+
+import (
+	"os"
+
+	"github.com/giantswarm/microerror"
+	"sigs.k8s.io/yaml"
+
+	"github.com/giantswarm/standup/pkg/key"
+)
+
+// LoadPipelineConfigs reads pipeline configurations keyed by pipeline name from the given YAML file.
+func LoadPipelineConfigs(path string) (map[string]key.PipelineConfig, error) {
+	configData, err := os.ReadFile(path)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	pipelineConfigs := map[string]key.PipelineConfig{}
+	err = yaml.UnmarshalStrict(configData, &pipelineConfigs)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	for name, pipelineConfig := range pipelineConfigs {
+		if pipelineConfig.Name != "" && pipelineConfig.Name != name {
+			return nil, microerror.Maskf(invalidConfigError, "name %#q of pipeline %#q does not match its key", pipelineConfig.Name, name)
+		}
+		if pipelineConfig.NodeCount < 0 {
+			return nil, microerror.Maskf(invalidConfigError, "node count for pipeline %#q must not be negative", name)
+		}
+		if pipelineConfig.ClusterSpec != nil {
+			err = pipelineConfig.ClusterSpec.Validate()
+			if err != nil {
+				return nil, microerror.Maskf(invalidConfigError, "invalid cluster spec for pipeline %#q: %s", name, err)
+			}
+		}
+		switch pipelineConfig.ReadinessProfile {
+		case "", key.ReadinessProfileFull, key.ReadinessProfileMinimal:
+		default:
+			return nil, microerror.Maskf(invalidConfigError, "unknown readiness profile %#q for pipeline %#q", pipelineConfig.ReadinessProfile, name)
+		}
+	}
+
+	return pipelineConfigs, nil
+}
@@ -0,0 +1,101 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/giantswarm/standup/pkg/key"
+)
+
+func Test_LoadPipelineConfigs(t *testing.T) {
+	testCases := []struct {
+		name         string
+		input        string
+		expected     map[string]key.PipelineConfig
+		errorMatcher func(error) bool
+	}{
+		{
+			name: "case 0: full pipeline config",
+			input: `
+aws-china:
+  installation: aws-china
+  organizationSelector: giantswarm.io/conformance-testing=china
+  nodeCount: 3
+  readinessProfile: minimal
+  conformance:
+    focus: "\\[sig-network\\]"
+    skip: "\\[Serial\\]"
+  timeouts:
+    release: 30m
+    wait: 1h
+`,
+			expected: map[string]key.PipelineConfig{
+				"aws-china": {
+					Installation:         "aws-china",
+					OrganizationSelector: "giantswarm.io/conformance-testing=china",
+					NodeCount:            3,
+					ReadinessProfile:     key.ReadinessProfileMinimal,
+					Conformance: key.ConformanceConfig{
+						Focus: `\[sig-network\]`,
+						Skip:  `\[Serial\]`,
+					},
+					Timeouts: key.TimeoutsConfig{
+						Release: v1.Duration{Duration: 30 * time.Minute},
+						Wait:    v1.Duration{Duration: time.Hour},
+					},
+				},
+			},
+		},
+		{
+			name: "case 1: unknown readiness profile",
+			input: `
+generic:
+  readinessProfile: quick
+`,
+			errorMatcher: IsInvalidConfig,
+		},
+		{
+			name: "case 2: mismatching name",
+			input: `
+generic:
+  name: aws-china
+`,
+			errorMatcher: IsInvalidConfig,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			path := filepath.Join(t.TempDir(), "pipelines.yaml")
+			err := os.WriteFile(path, []byte(tc.input), 0600)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := LoadPipelineConfigs(path)
+
+			switch {
+			case err == nil && tc.errorMatcher == nil:
+				// correct; carry on
+			case err != nil && tc.errorMatcher == nil:
+				t.Fatalf("error == %#v, want nil", err)
+			case err == nil && tc.errorMatcher != nil:
+				t.Fatalf("error == nil, want non-nil")
+			case !tc.errorMatcher(err):
+				t.Fatalf("error == %#v, want matching", err)
+			}
+
+			if !cmp.Equal(result, tc.expected) {
+				t.Fatalf("\n\n%s\n", cmp.Diff(tc.expected, result))
+			}
+		})
+	}
+}
@@ -0,0 +1,233 @@
+// Package credentials manages the ServiceAccount whose token the tests use to
+// access a test cluster, instead of a client certificate which cannot be
+// revoked before it expires.
+package credentials
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/giantswarm/standup/pkg/key"
+)
+
+const (
+	// Name is the name of the ServiceAccount, its token Secret and ClusterRoleBinding.
+	Name = "standup"
+	// Namespace is the namespace of the ServiceAccount and its token Secret.
+	Namespace = "kube-system"
+
+	contextName = "standup"
+)
+
+type Config struct {
+	// K8sClient must be allowed to manage ServiceAccounts and ClusterRoleBindings, e.g. using a
+	// short-lived admin certificate.
+	K8sClient kubernetes.Interface
+	Logger    micrologger.Logger
+
+	// Server is the URL of the API of the test cluster.
+	Server string
+	// CAData is the CA certificate of the API of the test cluster.
+	CAData []byte
+}
+
+type Credentials struct {
+	k8sClient kubernetes.Interface
+	logger    micrologger.Logger
+
+	server string
+	caData []byte
+}
+
+func New(config Config) (*Credentials, error) {
+	if config.K8sClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.K8sClient must not be empty", config)
+	}
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+	if config.Server == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Server must not be empty", config)
+	}
+
+	c := &Credentials{
+		k8sClient: config.K8sClient,
+		logger:    config.Logger,
+
+		server: config.Server,
+		caData: config.CAData,
+	}
+
+	return c, nil
+}
+
+// Create creates the ServiceAccount bound to the given ClusterRole and returns
+// a kubeconfig authenticating with its token. It is idempotent and returns
+// tokenNotReadyError until the token controller has populated the token, so
+// it is meant to be retried.
+func (c *Credentials) Create(ctx context.Context, clusterRole string) ([]byte, error) {
+	labels := map[string]string{
+		key.LabelTesting: "true",
+	}
+
+	{
+		serviceAccount := &corev1.ServiceAccount{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      Name,
+				Namespace: Namespace,
+				Labels:    labels,
+			},
+		}
+		_, err := c.k8sClient.CoreV1().ServiceAccounts(Namespace).Create(ctx, serviceAccount, metav1.CreateOptions{})
+		if err != nil && !apierrors.IsAlreadyExists(err) {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	{
+		binding := &rbacv1.ClusterRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   Name,
+				Labels: labels,
+			},
+			RoleRef: rbacv1.RoleRef{
+				APIGroup: rbacv1.GroupName,
+				Kind:     "ClusterRole",
+				Name:     clusterRole,
+			},
+			Subjects: []rbacv1.Subject{
+				{
+					Kind:      rbacv1.ServiceAccountKind,
+					Name:      Name,
+					Namespace: Namespace,
+				},
+			},
+		}
+		err := c.createClusterRoleBinding(ctx, binding)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	// The token Secret is created explicitly, as newer Kubernetes versions no
+	// longer create one for each ServiceAccount.
+	{
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      Name,
+				Namespace: Namespace,
+				Labels:    labels,
+				Annotations: map[string]string{
+					corev1.ServiceAccountNameKey: Name,
+				},
+			},
+			Type: corev1.SecretTypeServiceAccountToken,
+		}
+		_, err := c.k8sClient.CoreV1().Secrets(Namespace).Create(ctx, secret, metav1.CreateOptions{})
+		if err != nil && !apierrors.IsAlreadyExists(err) {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	var token []byte
+	{
+		secret, err := c.k8sClient.CoreV1().Secrets(Namespace).Get(ctx, Name, metav1.GetOptions{})
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		token = secret.Data[corev1.ServiceAccountTokenKey]
+		if len(token) == 0 {
+			return nil, microerror.Maskf(tokenNotReadyError, "secret %s/%s has no token yet", Namespace, Name)
+		}
+	}
+
+	kubeconfig := clientcmdapi.NewConfig()
+	kubeconfig.Clusters[contextName] = &clientcmdapi.Cluster{
+		Server:                   c.server,
+		CertificateAuthorityData: c.caData,
+	}
+	kubeconfig.AuthInfos[contextName] = &clientcmdapi.AuthInfo{
+		Token: string(token),
+	}
+	kubeconfig.Contexts[contextName] = &clientcmdapi.Context{
+		Cluster:  contextName,
+		AuthInfo: contextName,
+	}
+	kubeconfig.CurrentContext = contextName
+
+	data, err := clientcmd.Write(*kubeconfig)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return data, nil
+}
+
+// createClusterRoleBinding creates the given ClusterRoleBinding. An existing
+// binding to another role, e.g. of a previous run with a different cluster
+// role, is recreated, as the role of a binding cannot be changed.
+func (c *Credentials) createClusterRoleBinding(ctx context.Context, binding *rbacv1.ClusterRoleBinding) error {
+	_, err := c.k8sClient.RbacV1().ClusterRoleBindings().Create(ctx, binding, metav1.CreateOptions{})
+	if err == nil {
+		return nil
+	} else if !apierrors.IsAlreadyExists(err) {
+		return microerror.Mask(err)
+	}
+
+	existing, err := c.k8sClient.RbacV1().ClusterRoleBindings().Get(ctx, binding.Name, metav1.GetOptions{})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	if existing.RoleRef == binding.RoleRef {
+		return nil
+	}
+
+	c.logger.LogCtx(ctx, "message", fmt.Sprintf("recreating cluster role binding %s bound to %s %s", binding.Name, existing.RoleRef.Kind, existing.RoleRef.Name))
+
+	err = c.k8sClient.RbacV1().ClusterRoleBindings().Delete(ctx, binding.Name, metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return microerror.Mask(err)
+	}
+	_, err = c.k8sClient.RbacV1().ClusterRoleBindings().Create(ctx, binding, metav1.CreateOptions{})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// Revoke deletes the ServiceAccount, its token and ClusterRoleBinding, which
+// invalidates kubeconfigs created with Create. Missing resources are ignored.
+func (c *Credentials) Revoke(ctx context.Context) error {
+	c.logger.LogCtx(ctx, "message", fmt.Sprintf("revoking service account %s/%s", Namespace, Name))
+
+	err := c.k8sClient.RbacV1().ClusterRoleBindings().Delete(ctx, Name, metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return microerror.Mask(err)
+	}
+
+	err = c.k8sClient.CoreV1().Secrets(Namespace).Delete(ctx, Name, metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return microerror.Mask(err)
+	}
+
+	err = c.k8sClient.CoreV1().ServiceAccounts(Namespace).Delete(ctx, Name, metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return microerror.Mask(err)
+	}
+
+	c.logger.LogCtx(ctx, "message", fmt.Sprintf("revoked service account %s/%s", Namespace, Name))
+
+	return nil
+}
@@ -0,0 +1,93 @@
+package credentials
+
+import (
+	"context"
+	"testing"
+
+	"github.com/giantswarm/micrologger/microloggertest"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+func Test_Credentials(t *testing.T) {
+	ctx := context.Background()
+	k8sClient := fake.NewSimpleClientset()
+
+	c, err := New(Config{
+		K8sClient: k8sClient,
+		Logger:    microloggertest.New(),
+
+		Server: "https://api.abc12.k8s.example.com",
+		CAData: []byte("ca"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The token controller has not populated the token yet.
+	_, err = c.Create(ctx, "edit")
+	if !IsTokenNotReady(err) {
+		t.Fatalf("error == %#v, want tokenNotReadyError", err)
+	}
+
+	secret, err := k8sClient.CoreV1().Secrets(Namespace).Get(ctx, Name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret.Data = map[string][]byte{corev1.ServiceAccountTokenKey: []byte("token")}
+	_, err = k8sClient.CoreV1().Secrets(Namespace).Update(ctx, secret, metav1.UpdateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := c.Create(ctx, "edit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	restConfig, err := clientcmd.RESTConfigFromKubeConfig(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restConfig.Host != "https://api.abc12.k8s.example.com" || restConfig.BearerToken != "token" || string(restConfig.CAData) != "ca" {
+		t.Fatalf("kubeconfig = %s, want server, token and CA of the service account", data)
+	}
+
+	binding, err := k8sClient.RbacV1().ClusterRoleBindings().Get(ctx, Name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if binding.RoleRef.Name != "edit" {
+		t.Fatalf("cluster role = %#q, want %#q", binding.RoleRef.Name, "edit")
+	}
+
+	// A binding to another cluster role is recreated.
+	_, err = c.Create(ctx, "view")
+	if err != nil {
+		t.Fatal(err)
+	}
+	binding, err = k8sClient.RbacV1().ClusterRoleBindings().Get(ctx, Name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if binding.RoleRef.Name != "view" {
+		t.Fatalf("cluster role = %#q, want %#q", binding.RoleRef.Name, "view")
+	}
+
+	err = c.Revoke(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = k8sClient.CoreV1().ServiceAccounts(Namespace).Get(ctx, Name, metav1.GetOptions{})
+	if !apierrors.IsNotFound(err) {
+		t.Fatalf("error == %#v, want not found", err)
+	}
+
+	// Revoking again is a no-op.
+	err = c.Revoke(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
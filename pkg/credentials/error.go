@@ -0,0 +1,21 @@
+package credentials
+
+import "github.com/giantswarm/microerror"
+
+var invalidConfigError = &microerror.Error{
+	Kind: "invalidConfigError",
+}
+
+// IsInvalidConfig asserts invalidConfigError.
+func IsInvalidConfig(err error) bool {
+	return microerror.Cause(err) == invalidConfigError
+}
+
+var tokenNotReadyError = &microerror.Error{
+	Kind: "tokenNotReadyError",
+}
+
+// IsTokenNotReady asserts tokenNotReadyError.
+func IsTokenNotReady(err error) bool {
+	return microerror.Cause(err) == tokenNotReadyError
+}
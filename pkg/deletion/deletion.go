@@ -0,0 +1,376 @@
+// Package deletion explains why the deletion of a namespace or object does
+// not progress: its remaining finalizers, its deletion conditions and, for
+// namespaces, the objects still in it. Known-safe finalizers can be stripped
+// to unblock test resources whose operators will not finish them.
+package deletion
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+)
+
+// SafeFinalizers are the finalizers which may be stripped from test
+// resources. They are set by Giant Swarm operators whose cleanup only concerns
+// management cluster state of the deleted test cluster. Finalizers guarding
+// cloud provider or workload cluster resources, e.g. of aws-operator or
+// app-operator, and finalizers of Kubernetes itself are never stripped.
+var SafeFinalizers = []string{
+	// cert-operator deletes the certificate secrets of the cluster.
+	"operatorkit.giantswarm.io/cert-operator",
+	// cluster-operator deletes the App CRs, CertConfigs and secrets in the
+	// cluster namespace.
+	"operatorkit.giantswarm.io/cluster-operator",
+	// release-operator deletes the operator App CRs of the release.
+	"operatorkit.giantswarm.io/release-operator",
+}
+
+var namespaceResource = schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}
+
+// Object identifies an object which is not deleted yet.
+type Object struct {
+	Resource   schema.GroupVersionResource
+	Kind       string
+	Namespace  string
+	Name       string
+	Finalizers []string
+	Labels     map[string]string
+}
+
+func (o Object) String() string {
+	if o.Namespace == "" {
+		return fmt.Sprintf("%s %s", o.Kind, o.Name)
+	}
+
+	return fmt.Sprintf("%s %s/%s", o.Kind, o.Namespace, o.Name)
+}
+
+// Diagnosis describes why an object is not deleted yet.
+type Diagnosis struct {
+	Object Object
+	// Conditions are the conditions of the object which are true, e.g.
+	// NamespaceDeletionContentFailure, formatted as "type: message".
+	Conditions []string
+	// Remaining are the objects left in a namespace.
+	Remaining []Object
+}
+
+// Equal returns whether both diagnoses describe the same state, in which
+// case the deletion made no progress in between.
+func (d Diagnosis) Equal(o Diagnosis) bool {
+	return d.String() == o.String()
+}
+
+// String summarizes the diagnosis in a single line, grouping the remaining
+// objects by kind.
+func (d Diagnosis) String() string {
+	parts := []string{
+		fmt.Sprintf("finalizers %v", d.Object.Finalizers),
+	}
+	if len(d.Conditions) > 0 {
+		parts = append(parts, fmt.Sprintf("conditions [%s]", strings.Join(d.Conditions, "; ")))
+	}
+	if len(d.Remaining) > 0 {
+		parts = append(parts, fmt.Sprintf("remaining objects [%s]", Summarize(d.Remaining)))
+	}
+
+	return fmt.Sprintf("%s: %s", d.Object, strings.Join(parts, ", "))
+}
+
+// Summarize lists the given objects grouped by kind, together with their
+// finalizers.
+func Summarize(objects []Object) string {
+	var kinds []string
+	for _, o := range objects {
+		kinds = appendUnique(kinds, o.Kind)
+	}
+	sort.Strings(kinds)
+
+	var groups []string
+	for _, kind := range kinds {
+		var names []string
+		var finalizers []string
+		for _, o := range objects {
+			if o.Kind != kind {
+				continue
+			}
+			name := o.Name
+			if o.Namespace != "" {
+				name = o.Namespace + "/" + o.Name
+			}
+			names = append(names, name)
+			finalizers = appendUnique(finalizers, o.Finalizers...)
+		}
+		group := fmt.Sprintf("%d %s %v", len(names), kind, names)
+		if len(finalizers) > 0 {
+			group += fmt.Sprintf(" with finalizers %v", finalizers)
+		}
+		groups = append(groups, group)
+	}
+
+	return strings.Join(groups, "; ")
+}
+
+type Config struct {
+	DynClient dynamic.Interface
+	K8sClient kubernetes.Interface
+	Logger    micrologger.Logger
+}
+
+type Diagnoser struct {
+	dynClient dynamic.Interface
+	k8sClient kubernetes.Interface
+	logger    micrologger.Logger
+}
+
+func New(config Config) (*Diagnoser, error) {
+	if config.DynClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.DynClient must not be empty", config)
+	}
+	if config.K8sClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.K8sClient must not be empty", config)
+	}
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+
+	d := &Diagnoser{
+		dynClient: config.DynClient,
+		k8sClient: config.K8sClient,
+		logger:    config.Logger,
+	}
+
+	return d, nil
+}
+
+// Namespace diagnoses the deletion of the given namespace. It returns
+// notFoundError once the namespace is gone.
+func (d *Diagnoser) Namespace(ctx context.Context, name string) (Diagnosis, error) {
+	namespace, err := d.k8sClient.CoreV1().Namespaces().Get(ctx, name, v1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return Diagnosis{}, microerror.Maskf(notFoundError, "namespace %#q", name)
+	} else if err != nil {
+		return Diagnosis{}, microerror.Mask(err)
+	}
+
+	diagnosis := Diagnosis{
+		Object: Object{
+			Resource:   namespaceResource,
+			Kind:       "Namespace",
+			Name:       name,
+			Finalizers: namespace.Finalizers,
+		},
+	}
+	// The finalizer of Kubernetes itself waits for the namespace to be empty.
+	for _, f := range namespace.Spec.Finalizers {
+		diagnosis.Object.Finalizers = append(diagnosis.Object.Finalizers, string(f))
+	}
+	for _, c := range namespace.Status.Conditions {
+		if c.Status == corev1.ConditionTrue {
+			diagnosis.Conditions = append(diagnosis.Conditions, fmt.Sprintf("%s: %s", c.Type, c.Message))
+		}
+	}
+
+	diagnosis.Remaining, err = d.remaining(ctx, name)
+	if err != nil {
+		return Diagnosis{}, microerror.Mask(err)
+	}
+
+	return diagnosis, nil
+}
+
+// Object diagnoses the deletion of the given object. It returns
+// notFoundError once the object is gone.
+func (d *Diagnoser) Object(ctx context.Context, resource schema.GroupVersionResource, namespace, name string) (Diagnosis, error) {
+	obj, err := d.dynClient.Resource(resource).Namespace(namespace).Get(ctx, name, v1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return Diagnosis{}, microerror.Maskf(notFoundError, "%s %#q", resource.Resource, name)
+	} else if err != nil {
+		return Diagnosis{}, microerror.Mask(err)
+	}
+
+	diagnosis := Diagnosis{
+		Object: Object{
+			Resource:   resource,
+			Kind:       obj.GetKind(),
+			Namespace:  namespace,
+			Name:       name,
+			Finalizers: obj.GetFinalizers(),
+		},
+	}
+
+	conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	for _, c := range conditions {
+		condition, ok := c.(map[string]interface{})
+		if !ok || condition["status"] != string(corev1.ConditionTrue) {
+			continue
+		}
+		diagnosis.Conditions = append(diagnosis.Conditions, fmt.Sprintf("%v: %v", condition["type"], condition["message"]))
+	}
+
+	return diagnosis, nil
+}
+
+// ForceFinalizers strips the finalizers listed in SafeFinalizers from
+// the diagnosed object and the objects remaining in it. It returns the
+// objects it changed.
+func (d *Diagnoser) ForceFinalizers(ctx context.Context, diagnosis Diagnosis) ([]string, error) {
+	var changed []string
+	for _, o := range append([]Object{diagnosis.Object}, diagnosis.Remaining...) {
+		kept := unsafeFinalizers(o.Finalizers)
+		if len(kept) == len(o.Finalizers) {
+			continue
+		}
+		// The namespace finalizer of Kubernetes lives in the spec and is
+		// never part of the metadata finalizers.
+		if o.Resource == namespaceResource {
+			kept = metadataFinalizers(kept)
+		}
+
+		// Strategic merge patches are not supported by custom resources.
+		patch, err := json.Marshal(map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"finalizers": kept,
+			},
+		})
+		if err != nil {
+			return changed, microerror.Mask(err)
+		}
+		_, err = d.dynClient.Resource(o.Resource).Namespace(o.Namespace).Patch(ctx, o.Name, types.MergePatchType, patch, v1.PatchOptions{})
+		if apierrors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return changed, microerror.Mask(err)
+		}
+
+		d.logger.LogCtx(ctx, "level", "warning", "message", fmt.Sprintf("stripped finalizers of %s, keeping %v", o, kept))
+		changed = append(changed, o.String())
+	}
+
+	return changed, nil
+}
+
+// remaining lists the objects of every listable namespaced resource in the
+// given namespace. Resources which fail to list are skipped.
+func (d *Diagnoser) remaining(ctx context.Context, namespace string) ([]Object, error) {
+	lists, err := discovery.ServerPreferredNamespacedResources(d.k8sClient.Discovery())
+	if discovery.IsGroupDiscoveryFailedError(err) {
+		d.logger.LogCtx(ctx, "level", "warning", "message", "failed to discover some API groups", "stack", microerror.JSON(err))
+	} else if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	lists = discovery.FilteredBy(discovery.SupportsAllVerbs{Verbs: []string{"list"}}, lists)
+
+	var objects []Object
+	for _, list := range lists {
+		gv, err := schema.ParseGroupVersion(list.GroupVersion)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+		for _, r := range list.APIResources {
+			// Events are kept after their objects are deleted.
+			if strings.Contains(r.Name, "/") || r.Name == "events" {
+				continue
+			}
+
+			resource := gv.WithResource(r.Name)
+			items, err := d.dynClient.Resource(resource).Namespace(namespace).List(ctx, v1.ListOptions{})
+			if err != nil {
+				d.logger.LogCtx(ctx, "level", "warning", "message", fmt.Sprintf("failed to list %s in namespace %#q", resource, namespace), "stack", microerror.JSON(err))
+				continue
+			}
+			for _, item := range items.Items {
+				objects = append(objects, Object{
+					Resource:   resource,
+					Kind:       gv.WithKind(r.Kind).GroupKind().String(),
+					Namespace:  namespace,
+					Name:       item.GetName(),
+					Finalizers: item.GetFinalizers(),
+				})
+			}
+		}
+	}
+
+	sortObjects(objects)
+
+	return objects, nil
+}
+
+func sortObjects(objects []Object) {
+	sort.Slice(objects, func(i, j int) bool {
+		if objects[i].Kind != objects[j].Kind {
+			return objects[i].Kind < objects[j].Kind
+		}
+		if objects[i].Namespace != objects[j].Namespace {
+			return objects[i].Namespace < objects[j].Namespace
+		}
+		return objects[i].Name < objects[j].Name
+	})
+}
+
+func unsafeFinalizers(finalizers []string) []string {
+	kept := []string{}
+	for _, f := range finalizers {
+		if !IsSafeFinalizer(f) {
+			kept = append(kept, f)
+		}
+	}
+
+	return kept
+}
+
+// metadataFinalizers drops the namespace finalizer of Kubernetes, which
+// Namespace lists together with the metadata finalizers.
+func metadataFinalizers(finalizers []string) []string {
+	result := []string{}
+	for _, f := range finalizers {
+		if f != string(corev1.FinalizerKubernetes) {
+			result = append(result, f)
+		}
+	}
+
+	return result
+}
+
+// IsSafeFinalizer returns whether the finalizer may be stripped from test
+// resources.
+func IsSafeFinalizer(finalizer string) bool {
+	for _, f := range SafeFinalizers {
+		if finalizer == f {
+			return true
+		}
+	}
+
+	return false
+}
+
+func appendUnique(list []string, values ...string) []string {
+	for _, v := range values {
+		found := false
+		for _, l := range list {
+			if l == v {
+				found = true
+				break
+			}
+		}
+		if !found {
+			list = append(list, v)
+		}
+	}
+
+	return list
+}
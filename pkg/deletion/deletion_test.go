@@ -0,0 +1,288 @@
+package deletion
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/giantswarm/micrologger/microloggertest"
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+var appResource = schema.GroupVersionResource{Group: "application.giantswarm.io", Version: "v1alpha1", Resource: "apps"}
+
+func Test_Namespace(t *testing.T) {
+	testCases := []struct {
+		name         string
+		objects      []runtime.Object
+		expected     string
+		errorMatcher func(error) bool
+	}{
+		{
+			name:         "case 0: namespace is gone",
+			errorMatcher: IsNotFound,
+		},
+		{
+			name: "case 1: empty namespace",
+			objects: []runtime.Object{
+				newUnstructured("v1", "Namespace", "", "abc12"),
+			},
+			expected: "Namespace abc12: finalizers [kubernetes]",
+		},
+		{
+			name: "case 2: remaining objects with finalizers",
+			objects: []runtime.Object{
+				newUnstructured("v1", "Namespace", "", "abc12", "operatorkit.giantswarm.io/cluster-operator"),
+				newUnstructured("application.giantswarm.io/v1alpha1", "App", "abc12", "cert-exporter", "operatorkit.giantswarm.io/app-operator-app", "example.com/keep"),
+				newUnstructured("application.giantswarm.io/v1alpha1", "App", "abc12", "kiam", "operatorkit.giantswarm.io/app-operator-app"),
+				newUnstructured("v1", "ConfigMap", "abc12", "values"),
+			},
+			expected: "Namespace abc12: finalizers [operatorkit.giantswarm.io/cluster-operator kubernetes], conditions [NamespaceContentRemaining: Some resources are remaining], " +
+				"remaining objects [2 App.application.giantswarm.io [abc12/cert-exporter abc12/kiam] with finalizers [operatorkit.giantswarm.io/app-operator-app example.com/keep]; 1 ConfigMap [abc12/values]]",
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			d := newDiagnoser(t, tc.objects...)
+
+			diagnosis, err := d.Namespace(context.Background(), "abc12")
+
+			switch {
+			case err == nil && tc.errorMatcher == nil:
+				// correct; carry on
+			case err != nil && tc.errorMatcher == nil:
+				t.Fatalf("error == %#v, want nil", err)
+			case err == nil && tc.errorMatcher != nil:
+				t.Fatalf("error == nil, want non-nil")
+			case !tc.errorMatcher(err):
+				t.Fatalf("error == %#v, want matching", err)
+			}
+
+			if tc.errorMatcher == nil && diagnosis.String() != tc.expected {
+				t.Fatalf("\n\n%s\n", cmp.Diff(tc.expected, diagnosis.String()))
+			}
+		})
+	}
+}
+
+func Test_ForceFinalizers(t *testing.T) {
+	d := newDiagnoser(t,
+		newUnstructured("v1", "Namespace", "", "abc12", "operatorkit.giantswarm.io/cluster-operator"),
+		newUnstructured("application.giantswarm.io/v1alpha1", "App", "abc12", "cert-exporter", "operatorkit.giantswarm.io/app-operator-app", "example.com/keep"),
+		newUnstructured("v1", "ConfigMap", "abc12", "values", "example.com/keep"),
+	)
+
+	diagnosis, err := d.Namespace(context.Background(), "abc12")
+	if err != nil {
+		t.Fatalf("error == %#v, want nil", err)
+	}
+
+	changed, err := d.ForceFinalizers(context.Background(), diagnosis)
+	if err != nil {
+		t.Fatalf("error == %#v, want nil", err)
+	}
+	expectedChanged := []string{"Namespace abc12"}
+	if !cmp.Equal(changed, expectedChanged) {
+		t.Fatalf("\n\n%s\n", cmp.Diff(expectedChanged, changed))
+	}
+
+	app, err := d.dynClient.Resource(appResource).Namespace("abc12").Get(context.Background(), "cert-exporter", v1.GetOptions{})
+	if err != nil {
+		t.Fatalf("error == %#v, want nil", err)
+	}
+	expectedFinalizers := []string{"operatorkit.giantswarm.io/app-operator-app", "example.com/keep"}
+	if !cmp.Equal(app.GetFinalizers(), expectedFinalizers) {
+		t.Fatalf("\n\n%s\n", cmp.Diff(expectedFinalizers, app.GetFinalizers()))
+	}
+}
+
+func Test_IsSafeFinalizer(t *testing.T) {
+	testCases := []struct {
+		name      string
+		finalizer string
+		expected  bool
+	}{
+		{
+			name:      "case 0: allowlisted finalizer",
+			finalizer: "operatorkit.giantswarm.io/release-operator",
+			expected:  true,
+		},
+		{
+			name:      "case 1: finalizer guarding cloud provider resources",
+			finalizer: "operatorkit.giantswarm.io/aws-operator",
+			expected:  false,
+		},
+		{
+			name:      "case 2: allowlisted finalizer as prefix",
+			finalizer: "operatorkit.giantswarm.io/cluster-operator-workload",
+			expected:  false,
+		},
+		{
+			name:      "case 3: finalizer of Kubernetes",
+			finalizer: "kubernetes",
+			expected:  false,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			result := IsSafeFinalizer(tc.finalizer)
+
+			if result != tc.expected {
+				t.Fatalf("result == %t, want %t", result, tc.expected)
+			}
+		})
+	}
+}
+
+// newDiagnoser returns a Diagnoser whose clients know the given objects.
+// Namespaces are also known to the typed client, with the finalizer of
+// Kubernetes and a condition when they contain any other object.
+func newDiagnoser(t *testing.T, objects ...runtime.Object) *Diagnoser {
+	var namespaces []runtime.Object
+	for _, o := range objects {
+		u := o.(*unstructured.Unstructured)
+		if u.GetKind() != "Namespace" {
+			continue
+		}
+		namespace := &corev1.Namespace{
+			ObjectMeta: v1.ObjectMeta{Name: u.GetName(), Finalizers: u.GetFinalizers()},
+			Spec:       corev1.NamespaceSpec{Finalizers: []corev1.FinalizerName{corev1.FinalizerKubernetes}},
+		}
+		if len(objects) > 1 {
+			namespace.Status.Conditions = []corev1.NamespaceCondition{
+				{Type: corev1.NamespaceContentRemaining, Status: corev1.ConditionTrue, Message: "Some resources are remaining"},
+				{Type: corev1.NamespaceDeletionDiscoveryFailure, Status: corev1.ConditionFalse},
+			}
+		}
+		namespaces = append(namespaces, namespace)
+	}
+
+	k8sClient := fake.NewSimpleClientset(namespaces...)
+	k8sClient.Resources = []*v1.APIResourceList{
+		{
+			GroupVersion: "v1",
+			APIResources: []v1.APIResource{
+				{Name: "configmaps", Kind: "ConfigMap", Namespaced: true, Verbs: v1.Verbs{"list", "delete"}},
+				{Name: "events", Kind: "Event", Namespaced: true, Verbs: v1.Verbs{"list"}},
+				{Name: "namespaces", Kind: "Namespace", Verbs: v1.Verbs{"list", "delete"}},
+				{Name: "pods/log", Kind: "Pod", Namespaced: true, Verbs: v1.Verbs{"get"}},
+			},
+		},
+		{
+			GroupVersion: "application.giantswarm.io/v1alpha1",
+			APIResources: []v1.APIResource{
+				{Name: "apps", Kind: "App", Namespaced: true, Verbs: v1.Verbs{"list", "delete"}},
+			},
+		},
+	}
+
+	d, err := New(Config{
+		DynClient: dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), objects...),
+		K8sClient: k8sClient,
+		Logger:    microloggertest.New(),
+	})
+	if err != nil {
+		t.Fatalf("error == %#v, want nil", err)
+	}
+
+	return d
+}
+
+func newUnstructured(apiVersion, kind, namespace, name string, finalizers ...string) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{}
+	u.SetAPIVersion(apiVersion)
+	u.SetKind(kind)
+	u.SetNamespace(namespace)
+	u.SetName(name)
+	u.SetFinalizers(finalizers)
+
+	return u
+}
+
+func Test_Leftovers(t *testing.T) {
+	testCases := []struct {
+		name     string
+		objects  []runtime.Object
+		expected string
+	}{
+		{
+			name: "case 0: no leftovers",
+			objects: []runtime.Object{
+				newCRD("provider.giantswarm.io", "AWSConfig", "awsconfigs", "v1alpha1"),
+			},
+			expected: "",
+		},
+		{
+			name: "case 1: labelled custom resources of the cluster",
+			objects: []runtime.Object{
+				newCRD("provider.giantswarm.io", "AWSConfig", "awsconfigs", "v1alpha1"),
+				newCRD("infrastructure.giantswarm.io", "AWSCluster", "awsclusters", "v1alpha2"),
+				newCRD("example.com", "Unserved", "unserveds"),
+				withLabels(newUnstructured("provider.giantswarm.io/v1alpha1", "AWSConfig", "default", "abc12", "operatorkit.giantswarm.io/aws-operator"), map[string]string{"giantswarm.io/cluster": "abc12"}),
+				withLabels(newUnstructured("provider.giantswarm.io/v1alpha1", "AWSConfig", "default", "def34"), map[string]string{"giantswarm.io/cluster": "def34"}),
+				withLabels(newUnstructured("infrastructure.giantswarm.io/v1alpha2", "AWSCluster", "org-test", "abc12"), map[string]string{"giantswarm.io/cluster": "abc12"}),
+			},
+			expected: "1 AWSCluster.infrastructure.giantswarm.io [org-test/abc12]; 1 AWSConfig.provider.giantswarm.io [default/abc12] with finalizers [operatorkit.giantswarm.io/aws-operator]",
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			d := newDiagnoser(t, tc.objects...)
+
+			leftovers, err := d.Leftovers(context.Background(), "giantswarm.io/cluster=abc12")
+			if err != nil {
+				t.Fatalf("error == %#v, want nil", err)
+			}
+
+			result := Summarize(leftovers)
+			if result != tc.expected {
+				t.Fatalf("\n\n%s\n", cmp.Diff(tc.expected, result))
+			}
+		})
+	}
+}
+
+func newCRD(group, kind, plural string, versions ...string) *unstructured.Unstructured {
+	var specVersions []interface{}
+	for i, v := range versions {
+		specVersions = append(specVersions, map[string]interface{}{
+			"name":    v,
+			"served":  true,
+			"storage": i == 0,
+		})
+	}
+
+	u := newUnstructured("apiextensions.k8s.io/v1", "CustomResourceDefinition", "", plural+"."+group)
+	u.Object["spec"] = map[string]interface{}{
+		"group": group,
+		"names": map[string]interface{}{
+			"kind":   kind,
+			"plural": plural,
+		},
+		"versions": specVersions,
+	}
+
+	return u
+}
+
+func withLabels(u *unstructured.Unstructured, labels map[string]string) *unstructured.Unstructured {
+	u.SetLabels(labels)
+
+	return u
+}
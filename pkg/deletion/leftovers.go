@@ -0,0 +1,83 @@
+package deletion
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/giantswarm/microerror"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var crdResource = schema.GroupVersionResource{Group: "apiextensions.k8s.io", Version: "v1", Resource: "customresourcedefinitions"}
+
+// Leftovers lists the custom resources of every installed CRD, in all
+// namespaces, which match the given label selector. CRDs whose resources fail
+// to list are skipped with a warning.
+func (d *Diagnoser) Leftovers(ctx context.Context, selector string) ([]Object, error) {
+	crds, err := d.dynClient.Resource(crdResource).List(ctx, v1.ListOptions{})
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	var objects []Object
+	for _, crd := range crds.Items {
+		resource, kind, ok := crdResourceOf(crd)
+		if !ok {
+			continue
+		}
+
+		items, err := d.dynClient.Resource(resource).List(ctx, v1.ListOptions{
+			LabelSelector: selector,
+		})
+		if err != nil {
+			d.logger.LogCtx(ctx, "level", "warning", "message", fmt.Sprintf("failed to list %s", resource), "stack", microerror.JSON(err))
+			continue
+		}
+		for _, item := range items.Items {
+			objects = append(objects, Object{
+				Resource:   resource,
+				Kind:       kind,
+				Namespace:  item.GetNamespace(),
+				Name:       item.GetName(),
+				Finalizers: item.GetFinalizers(),
+				Labels:     item.GetLabels(),
+			})
+		}
+	}
+
+	sortObjects(objects)
+
+	return objects, nil
+}
+
+// crdResourceOf returns the resource of the storage version of the given CRD
+// and its kind qualified by group. CRDs without a served version are not
+// listable.
+func crdResourceOf(crd unstructured.Unstructured) (schema.GroupVersionResource, string, bool) {
+	group, _, _ := unstructured.NestedString(crd.Object, "spec", "group")
+	plural, _, _ := unstructured.NestedString(crd.Object, "spec", "names", "plural")
+	kind, _, _ := unstructured.NestedString(crd.Object, "spec", "names", "kind")
+	versions, _, _ := unstructured.NestedSlice(crd.Object, "spec", "versions")
+
+	var version string
+	for _, v := range versions {
+		m, ok := v.(map[string]interface{})
+		if !ok || m["served"] != true {
+			continue
+		}
+		name, _ := m["name"].(string)
+		if version == "" || m["storage"] == true {
+			version = name
+		}
+	}
+	if version == "" || plural == "" {
+		return schema.GroupVersionResource{}, "", false
+	}
+
+	resource := schema.GroupVersionResource{Group: group, Version: version, Resource: plural}
+	groupKind := schema.GroupKind{Group: group, Kind: kind}
+
+	return resource, groupKind.String(), true
+}
@@ -0,0 +1,175 @@
+// Package exitcode maps the errors of standup commands to documented exit
+// codes, so pipelines can branch on the class of a failure, and renders them
+// with a remediation hint for humans.
+package exitcode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"regexp"
+
+	"github.com/giantswarm/errors/tenant"
+	"github.com/giantswarm/microerror"
+)
+
+// Exit codes of standup. They are documented in the README and must not
+// change meaning once released.
+const (
+	OK              = 0
+	Unknown         = 1
+	InvalidFlag     = 2
+	Config          = 3
+	ReleaseNotFound = 4
+	NotReady        = 5
+	ClusterCreation = 6
+	ClusterNotFound = 7
+	APIUnreachable  = 8
+	Leftovers       = 9
+	Interrupted     = 130
+)
+
+// Class is the class of a failure.
+type Class struct {
+	Code int
+	Name string
+	Hint string
+}
+
+var (
+	unknown = Class{
+		Code: Unknown,
+		Name: "unexpected error",
+		Hint: "Run the command again with --log-level=debug and check the logs for details.",
+	}
+	invalidFlag = Class{
+		Code: InvalidFlag,
+		Name: "invalid flag",
+		Hint: "Check the flags of the command, see --help.",
+	}
+	config = Class{
+		Code: Config,
+		Name: "configuration error",
+		Hint: "Check the flags, STANDUP_* environment variables and the pipeline and provider configuration.",
+	}
+	authentication = Class{
+		Code: Config,
+		Name: "authentication failed",
+		Hint: "Check the credentials of the installation, e.g. the gsctl endpoint, username and password.",
+	}
+	releaseNotFound = Class{
+		Code: ReleaseNotFound,
+		Name: "release not found",
+		Hint: "Check the release version, and that `standup create release` ran against the same installation.",
+	}
+	notReady = Class{
+		Code: NotReady,
+		Name: "not ready in time",
+		Hint: "Check the operators and apps of the release in the management and workload cluster, or raise the timeouts of the pipeline.",
+	}
+	clusterCreation = Class{
+		Code: ClusterCreation,
+		Name: "cluster creation failed",
+		Hint: "Check the capacity and organizations of the installation and the logs of the cluster operators.",
+	}
+	clusterNotFound = Class{
+		Code: ClusterNotFound,
+		Name: "cluster not found",
+		Hint: "Check that the cluster ID in the output directory belongs to this installation and that the cluster was not deleted yet.",
+	}
+	apiUnreachable = Class{
+		Code: APIUnreachable,
+		Name: "API unreachable",
+		Hint: "Check the kubeconfig and network access to the API. Workload cluster APIs may take a while to become reachable after creation.",
+	}
+	leftovers = Class{
+		Code: Leftovers,
+		Name: "leftovers found",
+		Hint: "Check the operators of the provider for the listed objects of the deleted cluster and delete them manually if needed.",
+	}
+	interrupted = Class{
+		Code: Interrupted,
+		Name: "interrupted",
+		Hint: "The command was cancelled. Resources created so far were rolled back where possible.",
+	}
+)
+
+// kinds maps the kinds of microerror errors of all packages to their class.
+// Packages define their own errors of the same kind, so they are matched by
+// kind rather than identity.
+var kinds = map[string]Class{
+	"invalidFlagError":              invalidFlag,
+	"invalidFlagsError":             invalidFlag,
+	"invalidConfigError":            config,
+	"invalidSpecError":              config,
+	"invalidRequestsError":          config,
+	"authenticationError":           authentication,
+	"releaseNotFoundError":          releaseNotFound,
+	"releaseNotReadyError":          notReady,
+	"notReadyError":                 notReady,
+	"notYetDeletedError":            notReady,
+	"tokenNotReadyError":            notReady,
+	"clusterCreationError":          clusterCreation,
+	"noCapacityError":               clusterCreation,
+	"notAvailableOrganizationError": clusterCreation,
+	"clusterNotFoundError":          clusterNotFound,
+	"apiNotAvailableError":          apiUnreachable,
+	"leftoversError":                leftovers,
+}
+
+// cobraFlagErrorPattern matches the errors cobra returns for invalid
+// arguments and flags before any command runs.
+var cobraFlagErrorPattern = regexp.MustCompile(`^(unknown (shorthand )?flag|unknown command|required flag\(s\)|flag needs an argument|invalid argument|bad flag syntax|accepts .* arg\(s\))`)
+
+// serverErrorPattern matches the errors the Kubernetes API server returns
+// while it is not available yet.
+var serverErrorPattern = regexp.MustCompile(`an error on the server .*`)
+
+// Classify returns the class of the given error. Nil errors are OK.
+func Classify(err error) Class {
+	if err == nil {
+		return Class{Code: OK}
+	}
+
+	if errors.Is(err, context.Canceled) {
+		return interrupted
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return notReady
+	}
+
+	var mErr *microerror.Error
+	if errors.As(err, &mErr) {
+		if c, ok := kinds[mErr.Kind]; ok {
+			return c
+		}
+	}
+
+	cause := microerror.Cause(err)
+	if cobraFlagErrorPattern.MatchString(cause.Error()) {
+		return invalidFlag
+	}
+
+	var netErr net.Error
+	if tenant.IsAPINotAvailable(err) || serverErrorPattern.MatchString(cause.Error()) || errors.As(err, &netErr) {
+		return apiUnreachable
+	}
+
+	return unknown
+}
+
+// Render writes the error with its class and a remediation hint to w and
+// returns the exit code of the class.
+func Render(w io.Writer, err error) int {
+	c := Classify(err)
+	if c.Code == OK {
+		return OK
+	}
+
+	fmt.Fprintf(w, "Error (%s, exit code %d): %s\n", c.Name, c.Code, microerror.Pretty(err, false))
+	fmt.Fprintf(w, "Hint: %s\n", c.Hint)
+
+	return c.Code
+}
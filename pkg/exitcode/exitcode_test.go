@@ -0,0 +1,93 @@
+package exitcode
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/giantswarm/microerror"
+)
+
+func Test_Classify(t *testing.T) {
+	testCases := []struct {
+		name         string
+		err          error
+		expectedCode int
+	}{
+		{
+			name:         "case 0: no error",
+			err:          nil,
+			expectedCode: OK,
+		},
+		{
+			name:         "case 1: annotated invalid flag error",
+			err:          microerror.Maskf(&microerror.Error{Kind: "invalidFlagError"}, "--release must not be empty"),
+			expectedCode: InvalidFlag,
+		},
+		{
+			name:         "case 2: unknown cobra flag",
+			err:          errors.New("unknown flag: --relase"),
+			expectedCode: InvalidFlag,
+		},
+		{
+			name:         "case 3: masked release not found error",
+			err:          microerror.Mask(microerror.Mask(&microerror.Error{Kind: "releaseNotFoundError"})),
+			expectedCode: ReleaseNotFound,
+		},
+		{
+			name:         "case 4: wait timed out",
+			err:          microerror.Mask(context.DeadlineExceeded),
+			expectedCode: NotReady,
+		},
+		{
+			name:         "case 5: interrupted",
+			err:          microerror.Mask(context.Canceled),
+			expectedCode: Interrupted,
+		},
+		{
+			name:         "case 6: connection refused by the API",
+			err:          microerror.Mask(&url.Error{Op: "Get", URL: "https://api.abc12.k8s.example.com", Err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}}),
+			expectedCode: APIUnreachable,
+		},
+		{
+			name:         "case 7: cluster not found",
+			err:          microerror.Maskf(&microerror.Error{Kind: "clusterNotFoundError"}, "cluster abc12"),
+			expectedCode: ClusterNotFound,
+		},
+		{
+			name:         "case 8: unknown error",
+			err:          errors.New("something went wrong"),
+			expectedCode: Unknown,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			c := Classify(tc.err)
+			if c.Code != tc.expectedCode {
+				t.Fatalf("code = %d, want %d", c.Code, tc.expectedCode)
+			}
+		})
+	}
+}
+
+func Test_Render(t *testing.T) {
+	var out bytes.Buffer
+	code := Render(&out, microerror.Maskf(&microerror.Error{Kind: "releaseNotFoundError"}, "release %#q", "v13.0.0-dev"))
+	if code != ReleaseNotFound {
+		t.Fatalf("code = %d, want %d", code, ReleaseNotFound)
+	}
+
+	for _, part := range []string{"Error (release not found, exit code 4): ", "`v13.0.0-dev`", "\nHint: "} {
+		if !strings.Contains(out.String(), part) {
+			t.Fatalf("rendered %#q, want it to contain %#q", out.String(), part)
+		}
+	}
+}
@@ -1,13 +1,14 @@
 package git
 
 import (
+	"context"
 	"os/exec"
 	"strings"
 
 	"github.com/giantswarm/microerror"
 )
 
-func Diff(dir, ref string) (string, error) {
+func Diff(ctx context.Context, dir, ref string) (string, error) {
 	// Determine the files added in this branch not in master
 	argsArr := []string{
 		"diff",
@@ -17,7 +18,7 @@ func Diff(dir, ref string) (string, error) {
 		"--no-renames",    // disable rename detection so we always find new releases
 		"HEAD",            // base ref for the diff
 	}
-	diff, err := runGit(argsArr, dir)
+	diff, err := runGit(ctx, argsArr, dir)
 	if err != nil {
 		return "", microerror.Mask(err)
 	}
@@ -25,7 +26,7 @@ func Diff(dir, ref string) (string, error) {
 	return diff, nil
 }
 
-func Fetch(dir string) error {
+func Fetch(ctx context.Context, dir string) error {
 	// Fetch master so we can diff against it
 	argsArr := []string{
 		"fetch",
@@ -33,20 +34,20 @@ func Fetch(dir string) error {
 		"origin",
 		"master",
 	}
-	_, err := runGit(argsArr, dir)
+	_, err := runGit(ctx, argsArr, dir)
 	if err != nil {
 		return microerror.Mask(err)
 	}
 	return nil
 }
 
-func HeadSHA(dir string) (string, error) {
+func HeadSHA(ctx context.Context, dir string) (string, error) {
 	// Get repo's HEAD SHA.
 	argsArr := []string{
 		"rev-parse",
 		"HEAD",
 	}
-	repoName, err := runGit(argsArr, dir)
+	repoName, err := runGit(ctx, argsArr, dir)
 	if err != nil {
 		return "", microerror.Mask(err)
 	}
@@ -54,14 +55,14 @@ func HeadSHA(dir string) (string, error) {
 	return strings.TrimSpace(repoName), nil
 }
 
-func MergeBase(dir string) (string, error) {
+func MergeBase(ctx context.Context, dir string) (string, error) {
 	// Fetch master so we can diff against it
 	argsArr := []string{
 		"merge-base",
 		"HEAD",
 		"origin/master",
 	}
-	mergeBase, err := runGit(argsArr, dir)
+	mergeBase, err := runGit(ctx, argsArr, dir)
 	if err != nil {
 		return "", microerror.Mask(err)
 	}
@@ -69,8 +70,8 @@ func MergeBase(dir string) (string, error) {
 	return strings.TrimSpace(mergeBase), nil
 }
 
-func runGit(args []string, dir string) (string, error) {
-	cmd := exec.Command("git", args...)
+func runGit(ctx context.Context, args []string, dir string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
 	cmd.Dir = dir
 	output, err := cmd.Output()
 	if err != nil {
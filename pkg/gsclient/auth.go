@@ -0,0 +1,92 @@
+package gsclient
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"time"
+
+	cenkalti "github.com/cenkalti/backoff/v4"
+	"github.com/giantswarm/backoff"
+	"github.com/giantswarm/microerror"
+)
+
+const (
+	// authMaxWait is the maximum time spent retrying a failing login before
+	// giving up.
+	authMaxWait = 5 * time.Minute
+	// authMaxInterval is the maximum interval between two login attempts.
+	authMaxInterval = 1 * time.Minute
+)
+
+// unauthorizedPattern matches the messages gsctl prints when the API rejects
+// the session or token, e.g. "401 Unauthorized" or "NotAuthorizedError".
+var unauthorizedPattern = regexp.MustCompile(`(?i)(\b401\b|unauthori[sz]ed|not ?authori[sz]ed)`)
+
+// authenticate logs in with username and password unless the client already
+// has a valid session. Token based clients don't need to log in.
+func (c *Client) authenticate(ctx context.Context) error {
+	if c.token != "" {
+		return nil
+	}
+
+	c.authMutex.Lock()
+	defer c.authMutex.Unlock()
+
+	if c.authenticated {
+		return nil
+	}
+
+	o := func() error {
+		_, err := c.execGsctl(ctx, "login", "--username", c.username, "--password", c.password)
+		if IsAuthenticationError(err) {
+			// Rejected credentials do not become valid by retrying.
+			return backoff.Permanent(microerror.Mask(err))
+		} else if err != nil {
+			return microerror.Mask(err)
+		}
+
+		return nil
+	}
+	n := func(err error, d time.Duration) {
+		c.logger.LogCtx(ctx, "level", "warning", "message", fmt.Sprintf("failed to log in to %#q, retrying in %s", c.endpoint, d), "stack", microerror.JSON(err))
+	}
+	b := cenkalti.WithContext(backoff.NewExponential(authMaxWait, authMaxInterval), ctx)
+
+	err := backoff.RetryNotify(o, b, n)
+	if ctx.Err() != nil {
+		return microerror.Mask(ctx.Err())
+	} else if IsAuthenticationError(err) {
+		return microerror.Mask(err)
+	} else if err != nil {
+		return microerror.Maskf(authenticationError, "failed to log in to %#q as %#q: %s", c.endpoint, c.username, err)
+	}
+
+	c.authenticated = true
+
+	return nil
+}
+
+// reauthenticate drops the current session and logs in again.
+func (c *Client) reauthenticate(ctx context.Context) error {
+	c.authMutex.Lock()
+	c.authenticated = false
+	c.authMutex.Unlock()
+
+	err := c.authenticate(ctx)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+func isUnauthorizedOutput(outputs ...[]byte) bool {
+	for _, o := range outputs {
+		if unauthorizedPattern.Match(o) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,90 @@
+package gsclient
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/giantswarm/micrologger/microloggertest"
+)
+
+func Test_isUnauthorizedOutput(t *testing.T) {
+	testCases := []struct {
+		name     string
+		stdout   string
+		stderr   string
+		expected bool
+	}{
+		{
+			name:     "case 0: http status in stderr",
+			stderr:   "Error: 401 Unauthorized",
+			expected: true,
+		},
+		{
+			name:     "case 1: error kind in json output",
+			stdout:   `{"result": "error", "error": {"kind": "NotAuthorizedError"}}`,
+			expected: true,
+		},
+		{
+			name:     "case 2: unrelated failure",
+			stdout:   `{"result": "error", "error": {"kind": "ClusterNotFoundError"}}`,
+			stderr:   "cluster abc12 not found",
+			expected: false,
+		},
+		{
+			name:     "case 3: number containing 401",
+			stderr:   "release v14010.0.0 not found",
+			expected: false,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			result := isUnauthorizedOutput([]byte(tc.stdout), []byte(tc.stderr))
+			if result != tc.expected {
+				t.Fatalf("expected %t, got %t", tc.expected, result)
+			}
+		})
+	}
+}
+
+func Test_authenticate_rejected(t *testing.T) {
+	// A fake gsctl counts its calls and rejects every login.
+	dir := t.TempDir()
+	calls := filepath.Join(dir, "calls")
+	script := "#!/bin/sh\necho login >> " + calls + "\necho 'Error: 401 Unauthorized' >&2\nexit 1\n"
+	err := os.WriteFile(filepath.Join(dir, "gsctl"), []byte(script), 0700) //#nosec
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	c, err := New(Config{
+		Logger: microloggertest.New(),
+
+		Endpoint: "https://api.example.com",
+		Username: "standup",
+		Password: "wrong",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = c.authenticate(context.Background())
+	if !IsAuthenticationError(err) {
+		t.Fatalf("error == %#v, want authenticationError", err)
+	}
+
+	data, err := os.ReadFile(calls)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(data), "login"); n != 1 {
+		t.Fatalf("gsctl called %d times, want 1", n)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"errors"
 	"os/exec"
+	"sync"
 
 	"github.com/giantswarm/microerror"
 	"github.com/giantswarm/micrologger"
@@ -26,10 +27,17 @@ type Config struct {
 }
 
 type Client struct {
+	logger micrologger.Logger
+
 	endpoint string
 	password string
 	token    string
 	username string
+
+	// authMutex guards the session state below, which is shared by all calls
+	// made through the same client.
+	authMutex     sync.Mutex
+	authenticated bool
 }
 
 type GsctlCreateClusterOptions struct {
@@ -37,6 +45,8 @@ type GsctlCreateClusterOptions struct {
 	Owner      string
 	Name       string
 	Release    string
+	// File is the path of an optional cluster definition file.
+	File string
 }
 
 type GsctlDeleteClusterOptions struct {
@@ -63,7 +73,7 @@ func New(config Config) (*Client, error) {
 			return nil, microerror.Maskf(invalidConfigError, "%T.Username is required when password is given", config)
 		} else if config.Password == "" {
 			return nil, microerror.Maskf(invalidConfigError, "%T.Password is required when username is given", config)
-		} else if config.Token == "" {
+		} else if config.Token != "" {
 			return nil, microerror.Maskf(invalidConfigError, "%T.Token must not be provided when using username and password", config)
 		}
 	} else if config.Token == "" {
@@ -71,6 +81,8 @@ func New(config Config) (*Client, error) {
 	}
 
 	client := Client{
+		logger: config.Logger,
+
 		endpoint: config.Endpoint,
 		username: config.Username,
 		password: config.Password,
@@ -80,19 +92,6 @@ func New(config Config) (*Client, error) {
 	return &client, nil
 }
 
-func (c *Client) authenticate(ctx context.Context) error {
-	if c.token != "" {
-		return nil
-	}
-
-	_, err := c.runWithGsctl(ctx, "gsctl", "login", "--username", c.username, "--password", c.password)
-	if err != nil {
-		return microerror.Mask(err)
-	}
-
-	return nil
-}
-
 func (c *Client) gsctlCreateCluster(ctx context.Context, result interface{}, options GsctlCreateClusterOptions) ([]byte, error) {
 
 	gsctlArgs := []string{
@@ -102,6 +101,10 @@ func (c *Client) gsctlCreateCluster(ctx context.Context, result interface{}, opt
 		"--release", options.Release,
 	}
 
+	if options.File != "" {
+		gsctlArgs = append(gsctlArgs, "--file", options.File)
+	}
+
 	var output []byte
 	var err error
 
@@ -172,19 +175,46 @@ func (c *Client) gsctlListClusters(ctx context.Context, result interface{}, opti
 
 }
 
+// runWithGsctl executes gsctl with the given arguments against the configured
+// endpoint. When gsctl reports that the session is no longer valid, the client
+// re-authenticates once and repeats the call.
 func (c *Client) runWithGsctl(ctx context.Context, args ...string) ([]byte, error) {
+	output, err := c.execGsctl(ctx, args...)
+	if IsAuthenticationError(err) && c.token == "" {
+		c.logger.LogCtx(ctx, "level", "warning", "message", "gsctl session is no longer valid, re-authenticating")
+
+		err = c.reauthenticate(ctx)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		output, err = c.execGsctl(ctx, args...)
+	}
+	if err != nil {
+		return output, microerror.Mask(err)
+	}
+
+	return output, nil
+}
+
+func (c *Client) execGsctl(ctx context.Context, args ...string) ([]byte, error) {
 	args = append(args, "--endpoint", c.endpoint)
 	if c.token != "" {
 		args = append(args, "--auth-token", c.token)
 	}
 
 	var stdout bytes.Buffer
+	var stderr bytes.Buffer
 
 	gsctlCmd := exec.CommandContext(ctx, "gsctl", args...)
 	gsctlCmd.Stdout = &stdout
+	gsctlCmd.Stderr = &stderr
 
 	err := gsctlCmd.Run()
-	if err != nil {
+	var exitError *exec.ExitError
+	if errors.As(err, &exitError) && isUnauthorizedOutput(stdout.Bytes(), stderr.Bytes()) {
+		return stdout.Bytes(), microerror.Maskf(authenticationError, "endpoint %#q rejected the credentials: %s", c.endpoint, bytes.TrimSpace(stderr.Bytes()))
+	} else if err != nil {
 		return stdout.Bytes(), microerror.Mask(err)
 	}
 
@@ -194,7 +224,9 @@ func (c *Client) runWithGsctl(ctx context.Context, args ...string) ([]byte, erro
 func (c *Client) runWithGsctlJSON(ctx context.Context, result interface{}, args ...string) ([]byte, error) {
 	stdout, err := c.runWithGsctl(ctx, args...)
 	var exitError *exec.ExitError
-	if errors.As(err, &exitError) {
+	if IsAuthenticationError(err) {
+		return stdout, microerror.Mask(err)
+	} else if errors.As(err, &exitError) {
 		// Command started successfully and failed -> we want to parse the output JSON for more info
 		// Fall through
 	} else if err != nil {
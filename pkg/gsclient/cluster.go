@@ -3,13 +3,19 @@ package gsclient
 import (
 	"context"
 	"fmt"
+	"os"
 
 	"github.com/giantswarm/microerror"
+	"sigs.k8s.io/yaml"
 
+	"github.com/giantswarm/standup/pkg/clusterspec"
 	"github.com/giantswarm/standup/pkg/key"
 )
 
-func (c *Client) CreateCluster(ctx context.Context, organizationName, releaseVersion string) (string, error) {
+// CreateCluster creates a cluster with the given name in the given
+// organization and release. The optional spec overrides the installation
+// defaults, e.g. with node pools.
+func (c *Client) CreateCluster(ctx context.Context, organizationName, name, releaseVersion string, spec *clusterspec.Spec) (string, error) {
 	err := c.authenticate(ctx)
 	if err != nil {
 		return "", microerror.Mask(err)
@@ -18,10 +24,36 @@ func (c *Client) CreateCluster(ctx context.Context, organizationName, releaseVer
 	createOptions := GsctlCreateClusterOptions{
 		OutputType: OutputTypeJSON,
 		Owner:      organizationName,
-		Name:       releaseVersion,
+		Name:       name,
 		Release:    releaseVersion,
 	}
 
+	if spec != nil {
+		definition := newClusterDefinition(organizationName, name, releaseVersion, *spec)
+		data, err := yaml.Marshal(definition)
+		if err != nil {
+			return "", microerror.Mask(err)
+		}
+
+		f, err := os.CreateTemp("", "cluster-definition-*.yaml")
+		if err != nil {
+			return "", microerror.Mask(err)
+		}
+		defer os.Remove(f.Name())
+
+		_, err = f.Write(data)
+		if err != nil {
+			f.Close()
+			return "", microerror.Mask(err)
+		}
+		err = f.Close()
+		if err != nil {
+			return "", microerror.Mask(err)
+		}
+
+		createOptions.File = f.Name()
+	}
+
 	var response CreationResponse
 	output, err := c.gsctlCreateCluster(ctx, &response, createOptions)
 	if err != nil {
@@ -64,25 +96,29 @@ func (c *Client) DeleteCluster(ctx context.Context, clusterID string) error {
 	return nil
 }
 
-func (c *Client) GetClusterReleaseVersion(ctx context.Context, clusterID string) (string, error) {
-	err := c.authenticate(ctx)
-	if err != nil {
-		return "", microerror.Mask(err)
-	}
-
+func (c *Client) GetCluster(ctx context.Context, clusterID string) (ClusterEntry, error) {
 	response, err := c.ListClusters(ctx)
 	if err != nil {
-		return "", microerror.Mask(err)
+		return ClusterEntry{}, microerror.Mask(err)
 	}
 
 	for _, cluster := range response {
 		if cluster.ID == clusterID {
-			// Have to add back the leading v in the release name
-			return fmt.Sprintf("v%s", cluster.ReleaseVersion), nil
+			return cluster, nil
 		}
 	}
 
-	return "", microerror.Maskf(clusterNotFoundError, fmt.Sprintf("cluster %s was not found", clusterID))
+	return ClusterEntry{}, microerror.Maskf(clusterNotFoundError, fmt.Sprintf("cluster %s was not found", clusterID))
+}
+
+func (c *Client) GetClusterReleaseVersion(ctx context.Context, clusterID string) (string, error) {
+	cluster, err := c.GetCluster(ctx, clusterID)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	// Have to add back the leading v in the release name
+	return fmt.Sprintf("v%s", cluster.ReleaseVersion), nil
 }
 
 func (c *Client) ListClusters(ctx context.Context) ([]ClusterEntry, error) {
@@ -0,0 +1,61 @@
+package gsclient
+
+import (
+	"github.com/giantswarm/standup/pkg/clusterspec"
+)
+
+const clusterDefinitionAPIVersion = "v5"
+
+// newClusterDefinition translates a cluster spec into the definition file format of gsctl.
+func newClusterDefinition(organizationName, name, releaseVersion string, spec clusterspec.Spec) ClusterDefinition {
+	definition := ClusterDefinition{
+		APIVersion:     clusterDefinitionAPIVersion,
+		Owner:          organizationName,
+		Name:           name,
+		ReleaseVersion: releaseVersion,
+		Labels:         spec.Labels,
+	}
+
+	if spec.ControlPlane.HighAvailability {
+		definition.MasterNodes = &MasterNodesDefinition{
+			HighAvailability: true,
+		}
+	}
+
+	for _, np := range spec.NodePools {
+		nodePool := NodePoolDefinition{
+			Name: np.Name,
+		}
+
+		if len(np.AvailabilityZones) > 0 || np.AvailabilityZonesCount > 0 {
+			nodePool.AvailabilityZones = &AvailabilityZonesDefinition{
+				Number: np.AvailabilityZonesCount,
+				Zones:  np.AvailabilityZones,
+			}
+		}
+
+		if np.MinSize > 0 || np.MaxSize > 0 {
+			nodePool.Scaling = &ScalingDefinition{
+				Min: np.MinSize,
+				Max: np.MaxSize,
+			}
+			if nodePool.Scaling.Max == 0 {
+				nodePool.Scaling.Max = np.MinSize
+			}
+		}
+
+		if np.InstanceType != "" || np.VMSize != "" {
+			nodePool.NodeSpec = &NodeSpecDefinition{}
+			if np.InstanceType != "" {
+				nodePool.NodeSpec.AWS = &AWSNodeSpecDefinition{InstanceType: np.InstanceType}
+			}
+			if np.VMSize != "" {
+				nodePool.NodeSpec.Azure = &AzureNodeSpecDefinition{VMSize: np.VMSize}
+			}
+		}
+
+		definition.NodePools = append(definition.NodePools, nodePool)
+	}
+
+	return definition
+}
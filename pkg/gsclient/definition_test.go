@@ -0,0 +1,118 @@
+package gsclient
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/giantswarm/standup/pkg/clusterspec"
+)
+
+func Test_newClusterDefinition(t *testing.T) {
+	testCases := []struct {
+		name     string
+		spec     clusterspec.Spec
+		expected ClusterDefinition
+	}{
+		{
+			name: "case 0: empty spec",
+			spec: clusterspec.Spec{},
+			expected: ClusterDefinition{
+				APIVersion:     "v5",
+				Owner:          "conformance-testing",
+				Name:           "13.0.0",
+				ReleaseVersion: "13.0.0",
+			},
+		},
+		{
+			name: "case 1: HA control plane and node pools",
+			spec: clusterspec.Spec{
+				ControlPlane: clusterspec.ControlPlane{
+					HighAvailability: true,
+				},
+				Labels: map[string]string{
+					"giantswarm.io/testing": "true",
+				},
+				NodePools: []clusterspec.NodePool{
+					{
+						Name:              "pinned",
+						MinSize:           2,
+						AvailabilityZones: []string{"eu-central-1a"},
+						InstanceType:      "m5.xlarge",
+					},
+					{
+						Name:                   "spread",
+						MinSize:                1,
+						MaxSize:                3,
+						AvailabilityZonesCount: 2,
+						VMSize:                 "Standard_D4s_v3",
+					},
+				},
+			},
+			expected: ClusterDefinition{
+				APIVersion:     "v5",
+				Owner:          "conformance-testing",
+				Name:           "13.0.0",
+				ReleaseVersion: "13.0.0",
+				MasterNodes: &MasterNodesDefinition{
+					HighAvailability: true,
+				},
+				Labels: map[string]string{
+					"giantswarm.io/testing": "true",
+				},
+				NodePools: []NodePoolDefinition{
+					{
+						Name: "pinned",
+						AvailabilityZones: &AvailabilityZonesDefinition{
+							Zones: []string{"eu-central-1a"},
+						},
+						Scaling: &ScalingDefinition{Min: 2, Max: 2},
+						NodeSpec: &NodeSpecDefinition{
+							AWS: &AWSNodeSpecDefinition{InstanceType: "m5.xlarge"},
+						},
+					},
+					{
+						Name: "spread",
+						AvailabilityZones: &AvailabilityZonesDefinition{
+							Number: 2,
+						},
+						Scaling: &ScalingDefinition{Min: 1, Max: 3},
+						NodeSpec: &NodeSpecDefinition{
+							Azure: &AzureNodeSpecDefinition{VMSize: "Standard_D4s_v3"},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "case 2: labels only",
+			spec: clusterspec.Spec{
+				Labels: map[string]string{
+					"giantswarm.io/pipeline": "standup-12345",
+				},
+			},
+			expected: ClusterDefinition{
+				APIVersion:     "v5",
+				Owner:          "conformance-testing",
+				Name:           "13.0.0",
+				ReleaseVersion: "13.0.0",
+				Labels: map[string]string{
+					"giantswarm.io/pipeline": "standup-12345",
+				},
+			},
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			result := newClusterDefinition("conformance-testing", "13.0.0", "13.0.0", tc.spec)
+
+			if !cmp.Equal(result, tc.expected) {
+				t.Fatalf("\n\n%s\n", cmp.Diff(tc.expected, result))
+			}
+		})
+	}
+}
@@ -2,6 +2,15 @@ package gsclient
 
 import "github.com/giantswarm/microerror"
 
+var authenticationError = &microerror.Error{
+	Kind: "authenticationError",
+}
+
+// IsAuthenticationError asserts authenticationError.
+func IsAuthenticationError(err error) bool {
+	return microerror.Cause(err) == authenticationError
+}
+
 var clusterCreationError = &microerror.Error{
 	Kind: "clusterCreationError",
 }
@@ -2,20 +2,80 @@ package gsclient
 
 import (
 	"context"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/giantswarm/microerror"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
 )
 
-func (c *Client) CreateKubeconfig(ctx context.Context, clusterID, kubeconfigPath string) error {
+// KubeconfigOptions configures the key pair of a created kubeconfig.
+type KubeconfigOptions struct {
+	// CertificateOrganizations are the organizations of the client certificate, which Kubernetes
+	// maps to groups. Empty means no groups.
+	CertificateOrganizations []string
+	// TTL is the lifetime of the key pair. It is rounded up to full hours. Zero means the default
+	// of the API.
+	TTL time.Duration
+}
+
+// CreateKubeconfig creates a key pair for the cluster and writes a self-contained kubeconfig using
+// it to the given path, readable only by the current user.
+func (c *Client) CreateKubeconfig(ctx context.Context, clusterID, kubeconfigPath string, options KubeconfigOptions) error {
 	err := c.authenticate(ctx)
 	if err != nil {
 		return microerror.Mask(err)
 	}
 
-	_, err = c.runWithGsctl(ctx, "create", "kubeconfig", "--cluster", clusterID, "--certificate-organizations", "system:masters", "--force", "--self-contained", kubeconfigPath)
+	_, err = c.runWithGsctl(ctx, kubeconfigArgs(clusterID, kubeconfigPath, options)...)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	err = os.Chmod(kubeconfigPath, 0600)
 	if err != nil {
 		return microerror.Mask(err)
 	}
 
 	return nil
 }
+
+// CreateRESTConfig creates a key pair for the cluster and returns a REST config using it, without
+// keeping the kubeconfig on disk.
+func (c *Client) CreateRESTConfig(ctx context.Context, clusterID string, options KubeconfigOptions) (*rest.Config, error) {
+	dir, err := os.MkdirTemp("", "standup-kubeconfig-")
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	defer os.RemoveAll(dir)
+
+	kubeconfigPath := filepath.Join(dir, "kubeconfig")
+	err = c.CreateKubeconfig(ctx, clusterID, kubeconfigPath, options)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	restConfig, err := clientcmd.BuildConfigFromFlags("", kubeconfigPath)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return restConfig, nil
+}
+
+func kubeconfigArgs(clusterID, kubeconfigPath string, options KubeconfigOptions) []string {
+	args := []string{"create", "kubeconfig", "--cluster", clusterID}
+	if len(options.CertificateOrganizations) > 0 {
+		args = append(args, "--certificate-organizations", strings.Join(options.CertificateOrganizations, ","))
+	}
+	if options.TTL > 0 {
+		args = append(args, "--ttl", fmt.Sprintf("%dh", int(math.Ceil(options.TTL.Hours()))))
+	}
+
+	return append(args, "--force", "--self-contained", kubeconfigPath)
+}
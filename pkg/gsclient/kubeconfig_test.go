@@ -0,0 +1,43 @@
+package gsclient
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_kubeconfigArgs(t *testing.T) {
+	testCases := []struct {
+		name     string
+		options  KubeconfigOptions
+		expected []string
+	}{
+		{
+			name:     "case 0: defaults of the API",
+			options:  KubeconfigOptions{},
+			expected: []string{"create", "kubeconfig", "--cluster", "abc12", "--force", "--self-contained", "/out/kubeconfig"},
+		},
+		{
+			name: "case 1: organizations and TTL rounded up to full hours",
+			options: KubeconfigOptions{
+				CertificateOrganizations: []string{"system:masters", "conformance"},
+				TTL:                      90 * time.Minute,
+			},
+			expected: []string{"create", "kubeconfig", "--cluster", "abc12", "--certificate-organizations", "system:masters,conformance", "--ttl", "2h", "--force", "--self-contained", "/out/kubeconfig"},
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			result := kubeconfigArgs("abc12", "/out/kubeconfig", tc.options)
+
+			if !cmp.Equal(result, tc.expected) {
+				t.Fatalf("\n\n%s\n", cmp.Diff(tc.expected, result))
+			}
+		})
+	}
+}
@@ -1,8 +1,16 @@
 package gsclient
 
+import "time"
+
 type ClusterEntry struct {
-	ID             string `json:"id"`
-	ReleaseVersion string `json:"release_version"`
+	ID             string     `json:"id"`
+	CreateDate     time.Time  `json:"create_date"`
+	DeleteDate     *time.Time `json:"delete_date,omitempty"`
+	Name           string     `json:"name"`
+	Owner          string     `json:"owner"`
+	ReleaseVersion string     `json:"release_version"`
+	// Labels are only set for clusters which support labelling.
+	Labels map[string]string `json:"labels,omitempty"`
 }
 
 type CreationResponse struct {
@@ -19,3 +27,48 @@ type DeletionResponse struct {
 type Error struct {
 	Kind string `json:"kind"`
 }
+
+// ClusterDefinition is the v5 cluster definition file format accepted by `gsctl create cluster --file`.
+type ClusterDefinition struct {
+	APIVersion     string                 `json:"api_version"`
+	Owner          string                 `json:"owner"`
+	Name           string                 `json:"name"`
+	ReleaseVersion string                 `json:"release_version"`
+	MasterNodes    *MasterNodesDefinition `json:"master_nodes,omitempty"`
+	Labels         map[string]string      `json:"labels,omitempty"`
+	NodePools      []NodePoolDefinition   `json:"nodepools,omitempty"`
+}
+
+type MasterNodesDefinition struct {
+	HighAvailability bool `json:"high_availability"`
+}
+
+type NodePoolDefinition struct {
+	Name              string                       `json:"name,omitempty"`
+	AvailabilityZones *AvailabilityZonesDefinition `json:"availability_zones,omitempty"`
+	Scaling           *ScalingDefinition           `json:"scaling,omitempty"`
+	NodeSpec          *NodeSpecDefinition          `json:"node_spec,omitempty"`
+}
+
+type AvailabilityZonesDefinition struct {
+	Number int      `json:"number,omitempty"`
+	Zones  []string `json:"zones,omitempty"`
+}
+
+type ScalingDefinition struct {
+	Min int `json:"min"`
+	Max int `json:"max"`
+}
+
+type NodeSpecDefinition struct {
+	AWS   *AWSNodeSpecDefinition   `json:"aws,omitempty"`
+	Azure *AzureNodeSpecDefinition `json:"azure,omitempty"`
+}
+
+type AWSNodeSpecDefinition struct {
+	InstanceType string `json:"instance_type"`
+}
+
+type AzureNodeSpecDefinition struct {
+	VMSize string `json:"vm_size"`
+}
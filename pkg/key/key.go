@@ -1,7 +1,20 @@
 package key
 
 import (
+	"context"
 	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	cenkalti "github.com/cenkalti/backoff/v4"
+	"github.com/giantswarm/backoff"
+	"github.com/giantswarm/microerror"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/giantswarm/standup/pkg/clusterspec"
 )
 
 const (
@@ -9,15 +22,106 @@ const (
 	DefaultPipelineName = "generic"
 )
 
+const (
+	// ReadinessProfileFull runs every readiness check in `wait`.
+	ReadinessProfileFull = "full"
+	// ReadinessProfileMinimal only waits for the API to be reachable and the nodes to be ready.
+	ReadinessProfileMinimal = "minimal"
+)
+
+// DefaultOrganizationSelector selects the Organization CRs in which test clusters may be created.
+const DefaultOrganizationSelector = "giantswarm.io/conformance-testing=true"
+
+const (
+	// LabelCluster holds the ID of the workload cluster on the objects of the cluster in the management cluster.
+	LabelCluster = "giantswarm.io/cluster"
+	// LabelCAPICluster holds the name of the CAPI cluster on the CAPI and infrastructure CRs of the cluster.
+	LabelCAPICluster = "cluster.x-k8s.io/cluster-name"
+	// LabelReleaseVersion holds the release version, without leading v, on the objects of a workload cluster.
+	LabelReleaseVersion = "release.giantswarm.io/version"
+	// LabelEphemeralOrganization marks Organization CRs created for a single test cluster,
+	// which are deleted again together with the cluster.
+	LabelEphemeralOrganization = "giantswarm.io/ephemeral-organization"
+	// LabelTesting marks resources created for testing, for future garbage collection.
+	LabelTesting = "giantswarm.io/testing"
+)
+
+// AnnotationReleaseClusterPrefix prefixes the annotations on a Release CR naming the test clusters
+// created on it, one annotation per cluster, so `cleanup` only deletes a shared release with its
+// last cluster.
+const AnnotationReleaseClusterPrefix = "clusters.standup.giantswarm.io/"
+
+const (
+	// CapacityConfigMapName is the name of the ConfigMap in the management cluster holding the capacity semaphore.
+	CapacityConfigMapName = "standup-capacity"
+	// CapacityConfigMapNamespace is the namespace of the capacity semaphore ConfigMap.
+	CapacityConfigMapNamespace = "default"
+	// DefaultCapacityMaxSlotLifetime covers a full test run, as the slot is only renewed while `create cluster` runs.
+	DefaultCapacityMaxSlotLifetime = 4 * time.Hour
+)
+
+// DefaultRollbackGracePeriod fits into the default termination grace period of 30s of Tekton task pods.
+const DefaultRollbackGracePeriod = 25 * time.Second
+
+const (
+	// CredentialsCertificate writes a kubeconfig of the test cluster with a client certificate.
+	CredentialsCertificate = "certificate"
+	// CredentialsServiceAccount writes a kubeconfig of the test cluster with the token of a
+	// ServiceAccount bound to a ClusterRole, which `cleanup` revokes.
+	CredentialsServiceAccount = "service-account"
+
+	// DefaultCertificateOrganization maps client certificates to cluster admins.
+	DefaultCertificateOrganization = "system:masters"
+	// DefaultCertificateTTL lets client certificates outlive a test run, but not by much.
+	DefaultCertificateTTL = 24 * time.Hour
+	// DefaultServiceAccountClusterRole allows tests to manage workloads, but not RBAC or nodes.
+	DefaultServiceAccountClusterRole = "edit"
+)
+
+const (
+	OrganizationStrategyLeastLoaded = "least-loaded"
+	OrganizationStrategyRandom      = "random"
+)
+
 // PipelineConfig contains opinionated overrides for certain Tekton pipelines (as defined in https://github.com/giantswarm/test-infra).
 // For example, sending an AWS release to a China installation when running a China-specific pipeline.
 type PipelineConfig struct {
-	Name string
+	Name string `json:"name"`
 	// Installation is the name of the management cluster endpoint and kubeconfig to target for this pipeline.
-	Installation string
+	Installation string `json:"installation,omitempty"`
+	// OrganizationSelector is the label selector for Organization CRs in which test clusters are created.
+	OrganizationSelector string `json:"organizationSelector,omitempty"`
+	// NodeCount is the number of nodes `wait` expects to become ready.
+	NodeCount int `json:"nodeCount,omitempty"`
+	// ReadinessProfile selects the set of checks `wait` runs, see ReadinessProfileFull and ReadinessProfileMinimal.
+	ReadinessProfile string `json:"readinessProfile,omitempty"`
+	// Conformance configures the Kubernetes conformance tests run against the test cluster, see test.Config.
+	Conformance ConformanceConfig `json:"conformance,omitempty"`
+	// Timeouts limit how long individual steps wait. Zero values mean the tekton task determines maximum runtime.
+	Timeouts TimeoutsConfig `json:"timeouts,omitempty"`
+	// ClusterSpec defines node pools, control plane and labels of test clusters created in this pipeline.
+	ClusterSpec *clusterspec.Spec `json:"clusterSpec,omitempty"`
+	// Features overrides the detection of cluster features standup depends on, e.g. `external-dns: false`.
+	Features map[string]bool `json:"features,omitempty"`
+}
+
+// ConformanceConfig holds the E2E_FOCUS and E2E_SKIP regular expressions of the conformance tests.
+type ConformanceConfig struct {
+	Focus string `json:"focus,omitempty"`
+	Skip  string `json:"skip,omitempty"`
+}
+
+type TimeoutsConfig struct {
+	// Release limits the wait for a created release to become ready.
+	Release v1.Duration `json:"release,omitempty"`
+	// Cluster limits the wait for a kubeconfig of a created cluster.
+	Cluster v1.Duration `json:"cluster,omitempty"`
+	// Wait limits each readiness check in `wait`.
+	Wait v1.Duration `json:"wait,omitempty"`
 }
 
 // The configurations specified by these PipelineConfigs will override the default behavior.
+// They are the built-in defaults and can be extended or replaced with RegisterPipelineConfigs.
 var pipelineConfigs = map[string]PipelineConfig{
 	// generic preserves the default behavior.
 	DefaultPipelineName: {
@@ -44,20 +148,125 @@ func GetPipelineConfigByName(pipelineName string) (bool, PipelineConfig) {
 	pipelineConfig, ok := pipelineConfigs[pipelineName]
 	if ok {
 		// Return a copy so we don't modify the pipeline globally.
-		// Will need to deep copy if we ever have reference types in PipelineConfig objects.
 		result := pipelineConfig
+		if pipelineConfig.ClusterSpec != nil {
+			spec := *pipelineConfig.ClusterSpec
+			spec.Labels = map[string]string{}
+			for k, v := range pipelineConfig.ClusterSpec.Labels {
+				spec.Labels[k] = v
+			}
+			spec.NodePools = append([]clusterspec.NodePool{}, pipelineConfig.ClusterSpec.NodePools...)
+			result.ClusterSpec = &spec
+		}
+		if pipelineConfig.Features != nil {
+			result.Features = map[string]bool{}
+			for k, v := range pipelineConfig.Features {
+				result.Features[k] = v
+			}
+		}
 		return ok, result
 	}
 
 	return ok, PipelineConfig{}
 }
 
+// OrganizationSelectorForPipeline returns the Organization label selector of the given pipeline,
+// falling back to DefaultOrganizationSelector.
+func OrganizationSelectorForPipeline(pipelineName string) string {
+	pipelineConfig, ok := pipelineConfigs[pipelineName]
+	if ok && pipelineConfig.OrganizationSelector != "" {
+		return pipelineConfig.OrganizationSelector
+	}
+
+	return DefaultOrganizationSelector
+}
+
+// RegisterPipelineConfigs adds the given configurations, usually loaded from a file,
+// replacing built-in configurations of the same name.
+func RegisterPipelineConfigs(configs map[string]PipelineConfig) {
+	for name, c := range configs {
+		if c.Name == "" {
+			c.Name = name
+		}
+		pipelineConfigs[name] = c
+	}
+}
+
 func IsCapiRelease(releaseName string) bool {
 	return releaseName == "v20.0.0" || releaseName == "20.0.0"
 }
 
-func KubeconfigPath(base, provider string) (path string) {
-	return fmt.Sprintf("%s/%s", base, provider)
+// SupportsClusterDefinitionV5 returns whether clusters of the given provider and release support
+// node pools, and therefore v5 cluster definitions, i.e. AWS from v10 and Azure from v13.
+func SupportsClusterDefinitionV5(provider, releaseName string) bool {
+	version, err := semver.NewVersion(strings.TrimPrefix(releaseName, "v"))
+	if err != nil {
+		return false
+	}
+
+	switch provider {
+	case "aws", "aws-china":
+		return version.Major() >= 10
+	case "azure":
+		return version.Major() >= 13
+	default:
+		return false
+	}
+}
+
+// OrganizationOfNamespace returns the name of the organization owning the given
+// organization namespace, in which CAPI clusters are created.
+func OrganizationOfNamespace(namespace string) string {
+	return strings.TrimPrefix(namespace, "org-")
+}
+
+// ReleaseClusterAnnotation returns the annotation on a Release CR referencing the given cluster.
+func ReleaseClusterAnnotation(clusterID string) string {
+	return AnnotationReleaseClusterPrefix + clusterID
+}
+
+// ReleaseClusters returns the sorted IDs of the clusters referenced by the given annotations of a
+// Release CR.
+func ReleaseClusters(annotations map[string]string) []string {
+	var ids []string
+	for k := range annotations {
+		if strings.HasPrefix(k, AnnotationReleaseClusterPrefix) {
+			ids = append(ids, strings.TrimPrefix(k, AnnotationReleaseClusterPrefix))
+		}
+	}
+	sort.Strings(ids)
+
+	return ids
+}
+
+// CapacityHolderName returns a name identifying this standup process in the capacity semaphore.
+// In Tekton the hostname is the name of the task's pod.
+func CapacityHolderName() (string, error) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	return fmt.Sprintf("%s-%d", hostname, os.Getpid()), nil
+}
+
+// EphemeralOrganizationName returns the name for an ephemeral Organization CR ending in the given suffix.
+func EphemeralOrganizationName(suffix string) string {
+	return fmt.Sprintf("standup-%s", suffix)
+}
+
+// WaitBackOff returns the backoff used to poll for a resource. Without a timeout it retries
+// basically forever, so the tekton task determines maximum runtime. Retrying stops once the
+// context is done, e.g. when standup is interrupted.
+func WaitBackOff(ctx context.Context, timeout, interval time.Duration) backoff.BackOff {
+	var b backoff.BackOff
+	if timeout <= 0 {
+		b = backoff.NewMaxRetries(^uint64(0), interval)
+	} else {
+		b = backoff.NewConstant(timeout, interval)
+	}
+
+	return cenkalti.WithContext(b, ctx)
 }
 
 func PipelineConfigs() map[string]PipelineConfig {
@@ -67,8 +276,8 @@ func PipelineConfigs() map[string]PipelineConfig {
 
 func copyMap(src map[string]PipelineConfig) map[string]PipelineConfig {
 	dst := make(map[string]PipelineConfig)
-	for k, v := range src {
-		dst[k] = v
+	for k := range src {
+		_, dst[k] = GetPipelineConfigByName(k)
 	}
 	return dst
 }
@@ -0,0 +1,103 @@
+// Package kubeconfig resolves the REST config of the management cluster of an
+// installation. It supports a directory with one kubeconfig per installation,
+// a single kubeconfig with one context per installation, merged $KUBECONFIG
+// files and the in-cluster config. Exec credential plugins configured in a
+// kubeconfig are run by client-go.
+package kubeconfig
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/giantswarm/microerror"
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/giantswarm/standup/pkg/config"
+)
+
+const (
+	flagContext    = "context"
+	flagKubeconfig = "kubeconfig"
+)
+
+// Flags selects the kubeconfig and context of the management cluster.
+type Flags struct {
+	Context string
+	Path    string
+}
+
+// Init registers the kubeconfig flags on the command.
+func (f *Flags) Init(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&f.Path, flagKubeconfig, "k", "", `The path to a kubeconfig or a directory containing a kubeconfig per installation named after it. Defaults to the kubeconfig configured for the installation, $KUBECONFIG or the in-cluster config.`)
+	cmd.Flags().StringVar(&f.Context, flagContext, "", `The kubeconfig context of the management cluster. Defaults to the context configured for the installation, a context named after the installation or the current context.`)
+}
+
+// RESTConfig returns the REST config for the management cluster of the given
+// installation. Explicit --kubeconfig and --context flags take precedence over
+// the kubeconfig configuration of the installation.
+func (f *Flags) RESTConfig(installation string, c config.KubeconfigConfig) (*rest.Config, error) {
+	// The in-cluster config of the installation only applies unless it is
+	// overridden on the command line.
+	if c.InCluster && f.Path == "" && f.Context == "" {
+		restConfig, err := rest.InClusterConfig()
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		return restConfig, nil
+	}
+
+	// The default rules merge the files listed in $KUBECONFIG or fall back to
+	// ~/.kube/config.
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	{
+		path := f.Path
+		if path == "" {
+			path = c.Path
+		}
+
+		if path != "" {
+			info, err := os.Stat(path)
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+			if info.IsDir() {
+				path = filepath.Join(path, installation)
+			}
+			rules.ExplicitPath = path
+		}
+	}
+
+	overrides := &clientcmd.ConfigOverrides{}
+	{
+		context := f.Context
+		if context == "" {
+			context = c.Context
+		}
+
+		// A single kubeconfig may hold one context per installation.
+		if context == "" {
+			rawConfig, err := rules.Load()
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+			if _, ok := rawConfig.Contexts[installation]; ok {
+				context = installation
+			}
+		}
+
+		overrides.CurrentContext = context
+	}
+
+	// Without any kubeconfig this falls back to the in-cluster config.
+	restConfig, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, overrides).ClientConfig()
+	if clientcmd.IsEmptyConfig(err) {
+		return nil, microerror.Maskf(invalidConfigError, "no kubeconfig found for installation %#q, set --%s, $KUBECONFIG or the kubeconfig of the installation in the config file", installation, flagKubeconfig)
+	} else if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return restConfig, nil
+}
@@ -0,0 +1,132 @@
+package kubeconfig
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/giantswarm/standup/pkg/config"
+)
+
+func Test_RESTConfig(t *testing.T) {
+	testCases := []struct {
+		name         string
+		flags        Flags
+		config       config.KubeconfigConfig
+		expectedHost string
+		errorMatcher func(error) bool
+	}{
+		{
+			name:         "case 0: directory with one kubeconfig per installation",
+			flags:        Flags{Path: "kubeconfigs"},
+			expectedHost: "https://aws.example.com",
+		},
+		{
+			name:         "case 1: single kubeconfig with a context named after the installation",
+			flags:        Flags{Path: "kubeconfig"},
+			expectedHost: "https://aws.example.com",
+		},
+		{
+			name:         "case 2: configured context",
+			flags:        Flags{Path: "kubeconfig"},
+			config:       config.KubeconfigConfig{Context: "azure"},
+			expectedHost: "https://azure.example.com",
+		},
+		{
+			name:         "case 3: explicit context overrides the configured one",
+			flags:        Flags{Path: "kubeconfig", Context: "kvm"},
+			config:       config.KubeconfigConfig{Context: "azure"},
+			expectedHost: "https://kvm.example.com",
+		},
+		{
+			name:         "case 4: --kubeconfig overrides the configured path",
+			flags:        Flags{Path: "kubeconfig"},
+			config:       config.KubeconfigConfig{Path: "kubeconfigs", Context: "kvm"},
+			expectedHost: "https://kvm.example.com",
+		},
+		{
+			name:         "case 5: configured path without --kubeconfig",
+			config:       config.KubeconfigConfig{Path: "kubeconfig", Context: "azure"},
+			expectedHost: "https://azure.example.com",
+		},
+		{
+			name:         "case 6: --kubeconfig overrides the configured in-cluster config",
+			flags:        Flags{Path: "kubeconfig"},
+			config:       config.KubeconfigConfig{InCluster: true},
+			expectedHost: "https://aws.example.com",
+		},
+		{
+			name:         "case 7: no kubeconfig outside of a cluster",
+			flags:        Flags{Path: "empty"},
+			errorMatcher: IsInvalidConfig,
+		},
+	}
+
+	dir := t.TempDir()
+	{
+		err := os.Mkdir(filepath.Join(dir, "kubeconfigs"), 0700)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = clientcmd.WriteToFile(*newConfig("aws"), filepath.Join(dir, "kubeconfigs", "aws"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = clientcmd.WriteToFile(*newConfig("kvm", "aws", "azure"), filepath.Join(dir, "kubeconfig"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = clientcmd.WriteToFile(*clientcmdapi.NewConfig(), filepath.Join(dir, "empty"))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			if tc.flags.Path != "" {
+				tc.flags.Path = filepath.Join(dir, tc.flags.Path)
+			}
+			if tc.config.Path != "" {
+				tc.config.Path = filepath.Join(dir, tc.config.Path)
+			}
+
+			restConfig, err := tc.flags.RESTConfig("aws", tc.config)
+
+			switch {
+			case err == nil && tc.errorMatcher == nil:
+				// correct; carry on
+			case err != nil && tc.errorMatcher == nil:
+				t.Fatalf("error == %#v, want nil", err)
+			case err == nil && tc.errorMatcher != nil:
+				t.Fatalf("error == nil, want non-nil")
+			case !tc.errorMatcher(err):
+				t.Fatalf("error == %#v, want matching", err)
+			}
+
+			if tc.errorMatcher == nil && restConfig.Host != tc.expectedHost {
+				t.Fatalf("host = %#q, want %#q", restConfig.Host, tc.expectedHost)
+			}
+		})
+	}
+}
+
+// newConfig returns a kubeconfig with a context per installation, the first
+// being the current context.
+func newConfig(installations ...string) *clientcmdapi.Config {
+	c := clientcmdapi.NewConfig()
+	for _, installation := range installations {
+		c.Clusters[installation] = &clientcmdapi.Cluster{Server: "https://" + installation + ".example.com"}
+		c.AuthInfos[installation] = &clientcmdapi.AuthInfo{Token: "token"}
+		c.Contexts[installation] = &clientcmdapi.Context{Cluster: installation, AuthInfo: installation}
+	}
+	c.CurrentContext = installations[0]
+
+	return c
+}
@@ -0,0 +1,305 @@
+// Package logging implements the logger of standup. It writes the JSON lines
+// of micrologger or human-readable text, filtered by log level. Both can only
+// be chosen once flags have been parsed, so the logger is configured after it
+// has been handed to the commands.
+package logging
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	"github.com/go-stack/stack"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+const (
+	flagFormat = "log-format"
+	flagLevel  = "log-level"
+
+	envFormat = "STANDUP_LOG_FORMAT"
+	envLevel  = "STANDUP_LOG_LEVEL"
+)
+
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+
+	LevelDebug   = "debug"
+	LevelInfo    = "info"
+	LevelWarning = "warning"
+	LevelError   = "error"
+)
+
+// levels orders the log levels by severity. Messages without level are info.
+var levels = map[string]int{
+	LevelDebug:   0,
+	LevelInfo:    1,
+	LevelWarning: 2,
+	LevelError:   3,
+}
+
+// callerDepth is the depth of the caller of a Logger method when the caller
+// is evaluated by micrologger, which expects to be called directly.
+const callerDepth = 7
+
+// Flags configures the format and level of the logs of all commands.
+type Flags struct {
+	Format string
+	Level  string
+}
+
+// Init registers the logging flags on the root command, so they apply to all
+// commands. Flags which are not set default to STANDUP_LOG_* environment
+// variables.
+func (f *Flags) Init(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringVar(&f.Format, flagFormat, envOrDefault(envFormat, FormatJSON), fmt.Sprintf(`The format of log messages ('json' or 'text'). Defaults to $%s or 'json'.`, envFormat))
+	cmd.PersistentFlags().StringVar(&f.Level, flagLevel, envOrDefault(envLevel, LevelInfo), fmt.Sprintf(`The minimum level of log messages ('debug', 'info', 'warning' or 'error'). Defaults to $%s or 'info'.`, envLevel))
+}
+
+func (f *Flags) Validate() error {
+	if f.Format != FormatJSON && f.Format != FormatText {
+		return microerror.Maskf(invalidFlagError, "--%s must be %#q or %#q", flagFormat, FormatJSON, FormatText)
+	}
+	if _, ok := levels[f.Level]; !ok {
+		return microerror.Maskf(invalidFlagError, "--%s must be one of %#q, %#q, %#q or %#q", flagLevel, LevelDebug, LevelInfo, LevelWarning, LevelError)
+	}
+
+	return nil
+}
+
+type Config struct {
+	// Writer receives the log messages. Defaults to stdout.
+	Writer io.Writer
+}
+
+// Logger implements micrologger.Logger. It logs JSON at info level until it
+// is configured otherwise.
+type Logger struct {
+	out     *output
+	keyVals []interface{}
+}
+
+// output is shared by a logger and all loggers derived from it with With.
+type output struct {
+	mutex sync.Mutex
+
+	writer io.Writer
+	json   micrologger.Logger
+	format string
+	level  int
+	start  time.Time
+	tty    bool
+
+	// Spans which are currently open, innermost last, and whether the spinner
+	// line of the innermost span is currently shown.
+	spans       []span
+	spinnerDone chan struct{}
+	spinnerLine bool
+}
+
+func New(config Config) (*Logger, error) {
+	if config.Writer == nil {
+		config.Writer = os.Stdout
+	}
+
+	jsonLogger, err := micrologger.New(micrologger.Config{
+		Caller: func() interface{} {
+			return fmt.Sprintf("%+v", stack.Caller(callerDepth))
+		},
+		IOWriter: config.Writer,
+	})
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	l := &Logger{
+		out: &output{
+			writer: config.Writer,
+			json:   jsonLogger,
+			format: FormatJSON,
+			level:  levels[LevelInfo],
+			start:  time.Now(),
+			tty:    isTerminal(config.Writer),
+		},
+	}
+
+	return l, nil
+}
+
+// Configure sets format and level of the logger and all loggers derived from
+// it. The values must have been validated with Flags.Validate.
+func (l *Logger) Configure(format, level string) {
+	l.out.mutex.Lock()
+	defer l.out.mutex.Unlock()
+
+	l.out.format = format
+	l.out.level = levels[level]
+}
+
+func (l *Logger) Debugf(ctx context.Context, format string, params ...interface{}) {
+	l.write(ctx, []interface{}{"level", LevelDebug, "message", fmt.Sprintf(format, params...)})
+}
+
+func (l *Logger) Errorf(ctx context.Context, err error, format string, params ...interface{}) {
+	keyVals := []interface{}{"level", LevelError, "message", fmt.Sprintf(format, params...)}
+	if err != nil {
+		keyVals = append(keyVals, "stack", microerror.JSON(err))
+	}
+
+	l.write(ctx, keyVals)
+}
+
+func (l *Logger) Log(keyVals ...interface{}) {
+	l.write(context.Background(), keyVals)
+}
+
+func (l *Logger) LogCtx(ctx context.Context, keyVals ...interface{}) {
+	l.write(ctx, keyVals)
+}
+
+func (l *Logger) With(keyVals ...interface{}) micrologger.Logger {
+	withKeyVals := make([]interface{}, 0, len(l.keyVals)+len(keyVals))
+	withKeyVals = append(withKeyVals, l.keyVals...)
+	withKeyVals = append(withKeyVals, keyVals...)
+
+	return &Logger{
+		out:     l.out,
+		keyVals: withKeyVals,
+	}
+}
+
+// WithIncreasedCallerDepth returns the logger itself. The text format has no
+// caller and the JSON format already accounts for this wrapper.
+func (l *Logger) WithIncreasedCallerDepth() micrologger.Logger {
+	return l
+}
+
+func (l *Logger) write(ctx context.Context, keyVals []interface{}) {
+	if len(l.keyVals) > 0 {
+		keyVals = append(append([]interface{}{}, l.keyVals...), keyVals...)
+	}
+
+	level := levelOf(keyVals)
+
+	l.out.mutex.Lock()
+	if levels[level] < l.out.level {
+		l.out.mutex.Unlock()
+		return
+	}
+	if l.out.format == FormatJSON {
+		l.out.mutex.Unlock()
+		l.out.json.LogCtx(ctx, keyVals...)
+		return
+	}
+	defer l.out.mutex.Unlock()
+
+	l.out.writeLine(l.out.formatText(level, keyVals))
+}
+
+// formatText renders a message as elapsed time, level, message and the
+// remaining key/value pairs sorted by key. Only the annotation of errors is
+// shown instead of the whole stack.
+func (o *output) formatText(level string, keyVals []interface{}) string {
+	var message string
+	fields := map[string]string{}
+	for i := 0; i+1 < len(keyVals); i += 2 {
+		k := fmt.Sprint(keyVals[i])
+		v := keyVals[i+1]
+		switch k {
+		case "level", "caller", "time":
+			continue
+		case "message":
+			message = fmt.Sprint(v)
+		case "stack":
+			fields["error"] = errorMessage(v)
+		default:
+			fields[k] = fmt.Sprint(v)
+		}
+	}
+
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s %s", o.colour(colourGrey, fmt.Sprintf("[%7s]", o.elapsed())), o.colour(levelColours[level], fmt.Sprintf("%-7s", strings.ToUpper(level))), message)
+	for _, k := range keys {
+		fmt.Fprintf(&b, " %s=%q", k, fields[k])
+	}
+
+	return b.String()
+}
+
+// writeLine writes a line of text, removing the spinner first if it is shown.
+// The spinner is drawn again with its next frame. The caller holds the mutex.
+func (o *output) writeLine(line string) {
+	if o.spinnerLine {
+		fmt.Fprint(o.writer, "\r\033[K")
+		o.spinnerLine = false
+	}
+	fmt.Fprintln(o.writer, line)
+}
+
+func (o *output) elapsed() string {
+	return time.Since(o.start).Round(time.Second).String()
+}
+
+func levelOf(keyVals []interface{}) string {
+	for i := 0; i+1 < len(keyVals); i += 2 {
+		if keyVals[i] == "level" {
+			level := fmt.Sprint(keyVals[i+1])
+			if _, ok := levels[level]; ok {
+				return level
+			}
+		}
+	}
+
+	return LevelInfo
+}
+
+// errorMessage extracts the annotation or kind from the JSON of an error
+// stack logged with microerror.JSON.
+func errorMessage(v interface{}) string {
+	var e struct {
+		Annotation string `json:"annotation"`
+		Kind       string `json:"kind"`
+	}
+	err := json.Unmarshal([]byte(fmt.Sprint(v)), &e)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	if e.Annotation != "" {
+		return e.Annotation
+	}
+
+	return e.Kind
+}
+
+func envOrDefault(key, defaultValue string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+
+	return defaultValue
+}
+
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+
+	return term.IsTerminal(int(f.Fd()))
+}
@@ -0,0 +1,88 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/giantswarm/microerror"
+)
+
+func Test_Logger(t *testing.T) {
+	testCases := []struct {
+		name          string
+		format        string
+		level         string
+		log           func(l *Logger)
+		expectedParts []string
+		expectedEmpty bool
+	}{
+		{
+			name:   "case 0: debug messages are dropped at info level",
+			format: FormatJSON,
+			level:  LevelInfo,
+			log: func(l *Logger) {
+				l.LogCtx(context.Background(), "level", "debug", "message", "Unable to parse semver version")
+			},
+			expectedEmpty: true,
+		},
+		{
+			name:   "case 1: debug messages are logged at debug level",
+			format: FormatJSON,
+			level:  LevelDebug,
+			log: func(l *Logger) {
+				l.LogCtx(context.Background(), "level", "debug", "message", "Unable to parse semver version")
+			},
+			expectedParts: []string{`"message":"Unable to parse semver version"`, `pkg/logging/logging_test.go:36"`},
+		},
+		{
+			name:   "case 2: messages without level are logged as info",
+			format: FormatJSON,
+			level:  LevelWarning,
+			log: func(l *Logger) {
+				l.LogCtx(context.Background(), "message", "waiting for nodes to be ready")
+			},
+			expectedEmpty: true,
+		},
+		{
+			name:   "case 3: text shows level, message, sorted values and the error annotation",
+			format: FormatText,
+			level:  LevelInfo,
+			log: func(l *Logger) {
+				l.With("cluster", "abc12").LogCtx(context.Background(), "level", "warning", "message", "failed to renew", "stack", microerror.JSON(microerror.Maskf(invalidFlagError, "lease lost")))
+			},
+			expectedParts: []string{`WARNING failed to renew cluster="abc12" error="lease lost"`},
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			var out bytes.Buffer
+			l, err := New(Config{
+				Writer: &out,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			l.Configure(tc.format, tc.level)
+
+			tc.log(l)
+
+			if tc.expectedEmpty {
+				if out.Len() != 0 {
+					t.Fatalf("logged %#q, want nothing", out.String())
+				}
+				return
+			}
+			for _, part := range tc.expectedParts {
+				if !strings.Contains(out.String(), part) {
+					t.Fatalf("logged %#q, want it to contain %#q", out.String(), part)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+const (
+	colourBold   = "\033[1m"
+	colourCyan   = "\033[1;36m"
+	colourGreen  = "\033[32m"
+	colourGrey   = "\033[90m"
+	colourRed    = "\033[31m"
+	colourReset  = "\033[0m"
+	colourYellow = "\033[33m"
+)
+
+var levelColours = map[string]string{
+	LevelDebug:   colourGrey,
+	LevelInfo:    colourBold,
+	LevelWarning: colourYellow,
+	LevelError:   colourRed,
+}
+
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+const spinnerInterval = 100 * time.Millisecond
+
+type span struct {
+	name  string
+	start time.Time
+}
+
+// SpanProcessor returns an OpenTelemetry span processor which prints a header
+// when a command or lifecycle step starts and its outcome when it ends. On a
+// TTY a spinner shows the step currently waited for. It only prints in the
+// text format.
+func (l *Logger) SpanProcessor() sdktrace.SpanProcessor {
+	return &stepPrinter{out: l.out}
+}
+
+type stepPrinter struct {
+	out *output
+}
+
+func (p *stepPrinter) OnStart(_ context.Context, s sdktrace.ReadWriteSpan) {
+	o := p.out
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	if o.format != FormatText {
+		return
+	}
+
+	o.spans = append(o.spans, span{name: s.Name(), start: s.StartTime()})
+	o.writeLine(fmt.Sprintf("%s %s", o.colour(colourGrey, fmt.Sprintf("[%7s]", o.elapsed())), o.colour(colourCyan, "==> "+s.Name())))
+
+	if o.tty && o.spinnerDone == nil {
+		o.spinnerDone = make(chan struct{})
+		go o.spin(o.spinnerDone)
+	}
+}
+
+func (p *stepPrinter) OnEnd(s sdktrace.ReadOnlySpan) {
+	o := p.out
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	if o.format != FormatText {
+		return
+	}
+
+	for i := len(o.spans) - 1; i >= 0; i-- {
+		if o.spans[i].name == s.Name() {
+			o.spans = append(o.spans[:i], o.spans[i+1:]...)
+			break
+		}
+	}
+
+	duration := s.EndTime().Sub(s.StartTime()).Round(time.Second)
+	var outcome string
+	if s.Status().Code == codes.Error {
+		outcome = o.colour(colourRed, fmt.Sprintf("<== %s failed after %s: %s", s.Name(), duration, s.Status().Description))
+	} else {
+		outcome = o.colour(colourGreen, fmt.Sprintf("<== %s done in %s", s.Name(), duration))
+	}
+	o.writeLine(fmt.Sprintf("%s %s", o.colour(colourGrey, fmt.Sprintf("[%7s]", o.elapsed())), outcome))
+}
+
+// Shutdown stops the spinner and removes its line.
+func (p *stepPrinter) Shutdown(context.Context) error {
+	o := p.out
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	if o.spinnerDone != nil {
+		close(o.spinnerDone)
+		o.spinnerDone = nil
+	}
+	if o.spinnerLine {
+		fmt.Fprint(o.writer, "\r\033[K")
+		o.spinnerLine = false
+	}
+
+	return nil
+}
+
+func (p *stepPrinter) ForceFlush(context.Context) error {
+	return nil
+}
+
+// spin draws the spinner with the innermost open span and the time spent in
+// it until done is closed.
+func (o *output) spin(done <-chan struct{}) {
+	ticker := time.NewTicker(spinnerInterval)
+	defer ticker.Stop()
+
+	for frame := 0; ; frame++ {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			o.mutex.Lock()
+			if len(o.spans) > 0 {
+				current := o.spans[len(o.spans)-1]
+				waited := time.Since(current.start).Round(time.Second)
+				fmt.Fprintf(o.writer, "\r\033[K%s %s %s", o.colour(colourCyan, spinnerFrames[frame%len(spinnerFrames)]), current.name, o.colour(colourGrey, waited.String()))
+				o.spinnerLine = true
+			}
+			o.mutex.Unlock()
+		}
+	}
+}
+
+// colour wraps the text in the given colour when writing to a TTY.
+func (o *output) colour(colour, text string) string {
+	if !o.tty || colour == "" {
+		return text
+	}
+
+	return colour + text + colourReset
+}
@@ -0,0 +1,275 @@
+// Package metrics records how long the lifecycle steps of standup take and
+// whether they succeed. The metrics are pushed to a Prometheus Pushgateway or
+// written to an OpenMetrics text file when a command ends.
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/push"
+	"github.com/prometheus/common/expfmt"
+	"github.com/spf13/cobra"
+)
+
+const (
+	flagFile        = "metrics-file"
+	flagPushgateway = "metrics-pushgateway"
+
+	envFile        = "STANDUP_METRICS_FILE"
+	envPushgateway = "STANDUP_METRICS_PUSHGATEWAY"
+)
+
+const (
+	namespace = "standup"
+	job       = "standup"
+
+	labelInstallation = "installation"
+	labelProvider     = "provider"
+	labelRelease      = "release"
+	labelResult       = "result"
+	labelStep         = "step"
+
+	resultFailure = "failure"
+	resultSuccess = "success"
+)
+
+// Lifecycle steps whose duration and outcome are recorded.
+const (
+	StepAPI                  = "api"
+	StepCapacity             = "capacity"
+	StepCharts               = "charts"
+	StepCleanupHook          = "cleanup-hook"
+	StepClusterCreation      = "cluster-creation"
+	StepClusterDeletion      = "cluster-deletion"
+	StepCoreDNS              = "coredns"
+	StepCredentials          = "credentials"
+	StepExternalDNS          = "external-dns"
+	StepKubeconfig           = "kubeconfig"
+	StepNodes                = "nodes"
+	StepOrganizationDeletion = "organization-deletion"
+	StepReleaseCreation      = "release-creation"
+	StepReleaseDeletion      = "release-deletion"
+	StepReleaseReady         = "release-ready"
+	StepVerification         = "verification"
+)
+
+// Flags configures where the metrics of a command are sent.
+type Flags struct {
+	File        string
+	Pushgateway string
+}
+
+// Init registers the metrics flags on the command. Flags which are not set
+// default to the STANDUP_METRICS_* environment variables.
+func (f *Flags) Init(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&f.File, flagFile, os.Getenv(envFile), fmt.Sprintf(`The path of a file to write metrics to in OpenMetrics text format when the command ends. Defaults to $%s.`, envFile))
+	cmd.Flags().StringVar(&f.Pushgateway, flagPushgateway, os.Getenv(envPushgateway), fmt.Sprintf(`The URL of a Prometheus Pushgateway to push metrics to when the command ends. Defaults to $%s.`, envPushgateway))
+}
+
+// Labels identify what a step has been run against. Empty labels are unknown
+// to the command.
+type Labels struct {
+	Installation string
+	Provider     string
+	Release      string
+}
+
+type Config struct {
+	Logger micrologger.Logger
+
+	// Command is the name of the command, used to group the pushed metrics.
+	Command string
+	// File is the path of the OpenMetrics text file. Empty disables it.
+	File string
+	// Pushgateway is the URL of the Pushgateway. Empty disables pushing.
+	Pushgateway string
+	// Timeout limits the time spent pushing metrics.
+	Timeout time.Duration
+}
+
+type Recorder struct {
+	logger micrologger.Logger
+
+	command     string
+	file        string
+	pushgateway string
+	timeout     time.Duration
+
+	registry *prometheus.Registry
+	duration *prometheus.HistogramVec
+	total    *prometheus.CounterVec
+
+	mutex  sync.Mutex
+	labels Labels
+}
+
+func New(config Config) (*Recorder, error) {
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+	if config.Command == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Command must not be empty", config)
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 10 * time.Second
+	}
+
+	duration := prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "step_duration_seconds",
+			Help:      "Time it took a lifecycle step to succeed or fail.",
+			// 5 seconds up to about an hour and a half.
+			Buckets: prometheus.ExponentialBuckets(5, 2, 11),
+		},
+		[]string{labelProvider, labelInstallation, labelRelease, labelStep},
+	)
+	total := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "steps_total",
+			Help:      "Number of lifecycle steps run, by result.",
+		},
+		[]string{labelProvider, labelInstallation, labelRelease, labelStep, labelResult},
+	)
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(duration, total)
+
+	r := &Recorder{
+		logger: config.Logger,
+
+		command:     config.Command,
+		file:        config.File,
+		pushgateway: config.Pushgateway,
+		timeout:     config.Timeout,
+
+		registry: registry,
+		duration: duration,
+		total:    total,
+	}
+
+	return r, nil
+}
+
+// SetLabels sets the labels of all steps recorded from now on. Empty fields
+// keep the label set before, so labels can be added once they are known.
+func (r *Recorder) SetLabels(labels Labels) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if labels.Installation != "" {
+		r.labels.Installation = labels.Installation
+	}
+	if labels.Provider != "" {
+		r.labels.Provider = labels.Provider
+	}
+	if labels.Release != "" {
+		r.labels.Release = labels.Release
+	}
+}
+
+// Step starts timing the given step. The returned function records its
+// duration and whether it failed with the given error.
+func (r *Recorder) Step(step string) func(err error) {
+	start := time.Now()
+
+	return func(err error) {
+		r.mutex.Lock()
+		labels := r.labels
+		r.mutex.Unlock()
+
+		result := resultSuccess
+		if err != nil {
+			result = resultFailure
+		}
+
+		r.duration.WithLabelValues(labels.Provider, labels.Installation, labels.Release, step).Observe(time.Since(start).Seconds())
+		r.total.WithLabelValues(labels.Provider, labels.Installation, labels.Release, step, result).Inc()
+	}
+}
+
+// Flush writes the recorded metrics to the file and pushes them to the
+// Pushgateway, whichever is configured. It is meant to be called once when the
+// command ends, also when it failed.
+func (r *Recorder) Flush(ctx context.Context) error {
+	if r.file != "" {
+		err := r.writeFile()
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		r.logger.LogCtx(ctx, "message", fmt.Sprintf("wrote metrics to path %s", r.file))
+	}
+
+	if r.pushgateway != "" {
+		err := r.push(ctx)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		r.logger.LogCtx(ctx, "message", fmt.Sprintf("pushed metrics to %s", r.pushgateway))
+	}
+
+	return nil
+}
+
+// push replaces the metrics of this command run on the Pushgateway. Runs are
+// grouped by command and host, which is unique per Tekton task run.
+func (r *Recorder) push(ctx context.Context) error {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	err = push.New(r.pushgateway, job).
+		Gatherer(r.registry).
+		Grouping("command", r.command).
+		Grouping("instance", hostname).
+		PushContext(ctx)
+	if err != nil {
+		return microerror.Maskf(pushFailedError, "%s", err)
+	}
+
+	return nil
+}
+
+func (r *Recorder) writeFile() error {
+	families, err := r.registry.Gather()
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	f, err := os.Create(r.file) //#nosec
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	defer f.Close()
+
+	encoder := expfmt.NewEncoder(f, expfmt.FmtOpenMetrics_1_0_0)
+	for _, family := range families {
+		err = encoder.Encode(family)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	// Closing the encoder writes the final "# EOF" line of OpenMetrics.
+	closer, ok := encoder.(expfmt.Closer)
+	if ok {
+		err = closer.Close()
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	return nil
+}
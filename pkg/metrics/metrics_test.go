@@ -0,0 +1,103 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/giantswarm/micrologger"
+)
+
+func Test_Recorder_Flush(t *testing.T) {
+	testCases := []struct {
+		name          string
+		steps         map[string]error
+		expectedLines []string
+	}{
+		{
+			name: "case 0: successful and failed steps are counted by result",
+			steps: map[string]error{
+				StepReleaseCreation: nil,
+				StepReleaseReady:    errors.New("timed out"),
+			},
+			expectedLines: []string{
+				`standup_steps_total{installation="ginger",provider="aws",release="14.0.0",result="success",step="release-creation"} 1.0`,
+				`standup_steps_total{installation="ginger",provider="aws",release="14.0.0",result="failure",step="release-ready"} 1.0`,
+				`standup_step_duration_seconds_count{installation="ginger",provider="aws",release="14.0.0",step="release-ready"} 1`,
+				`# EOF`,
+			},
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			var pushedPath string
+			var pushedBody []byte
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				pushedPath = req.URL.Path
+				pushedBody, _ = io.ReadAll(req.Body)
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer server.Close()
+
+			logger, err := micrologger.New(micrologger.Config{
+				IOWriter: io.Discard,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			file := filepath.Join(t.TempDir(), "metrics.txt")
+			r, err := New(Config{
+				Logger: logger,
+
+				Command:     "release",
+				File:        file,
+				Pushgateway: server.URL,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r.SetLabels(Labels{Provider: "aws", Installation: "ginger"})
+			r.SetLabels(Labels{Release: "14.0.0"})
+			for step, stepErr := range tc.steps {
+				r.Step(step)(stepErr)
+			}
+
+			err = r.Flush(context.Background())
+			if err != nil {
+				t.Fatalf("error == %#v, want nil", err)
+			}
+
+			written, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, line := range tc.expectedLines {
+				if !strings.Contains(string(written), line+"\n") {
+					t.Fatalf("expected line %#q in\n%s", line, written)
+				}
+			}
+
+			// The order of grouping labels in the path is not defined.
+			for _, part := range []string{"/metrics/job/standup/", "/command/release", "/instance/"} {
+				if !strings.Contains(pushedPath, part) {
+					t.Fatalf("pushed to %#q, want grouping by command and instance", pushedPath)
+				}
+			}
+			if len(pushedBody) == 0 {
+				t.Fatalf("pushed empty body")
+			}
+		})
+	}
+}
@@ -0,0 +1,196 @@
+// Package pipelinerun describes the pipeline run on whose behalf standup
+// creates resources, so their owners can be traced later on.
+package pipelinerun
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	flagCommitSHA   = "commit-sha"
+	flagPipelineRun = "pipeline-run"
+	flagPullRequest = "pull-request"
+	flagRequester   = "requester"
+
+	envCommitSHA   = "STANDUP_COMMIT_SHA"
+	envPipelineRun = "STANDUP_PIPELINE_RUN"
+	envPullRequest = "STANDUP_PULL_REQUEST"
+	envRequester   = "STANDUP_REQUESTER"
+)
+
+const (
+	AnnotationCommitSHA   = "standup.giantswarm.io/commit-sha"
+	AnnotationPipelineRun = "standup.giantswarm.io/pipeline-run"
+	AnnotationPullRequest = "standup.giantswarm.io/pull-request"
+	AnnotationRequester   = "standup.giantswarm.io/requester"
+
+	LabelCommitSHA   = "standup.giantswarm.io/commit-sha"
+	LabelPipelineRun = "standup.giantswarm.io/pipeline-run"
+	LabelRequester   = "standup.giantswarm.io/requester"
+)
+
+// maxNameLength is the maximum length of a cluster name accepted by the API.
+const maxNameLength = 100
+
+var invalidLabelValueCharacters = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+type Metadata struct {
+	CommitSHA   string `json:"commitSHA,omitempty"`
+	PipelineRun string `json:"pipelineRun,omitempty"`
+	PullRequest string `json:"pullRequest,omitempty"`
+	Requester   string `json:"requester,omitempty"`
+}
+
+// FromAnnotations returns the metadata stored in the given annotations by
+// ObjectMeta.
+func FromAnnotations(annotations map[string]string) Metadata {
+	return Metadata{
+		CommitSHA:   annotations[AnnotationCommitSHA],
+		PipelineRun: annotations[AnnotationPipelineRun],
+		PullRequest: annotations[AnnotationPullRequest],
+		Requester:   annotations[AnnotationRequester],
+	}
+}
+
+// FromLabels returns the metadata stored in the given labels by ObjectMeta.
+// Label values are sanitized, so they may differ from the original metadata,
+// and the pull request is never stored in labels.
+func FromLabels(labels map[string]string) Metadata {
+	return Metadata{
+		CommitSHA:   labels[LabelCommitSHA],
+		PipelineRun: labels[LabelPipelineRun],
+		Requester:   labels[LabelRequester],
+	}
+}
+
+// ParseDescription returns the metadata appended to a cluster name by
+// Describe. Names without metadata, or truncated ones, yield empty metadata.
+func ParseDescription(description string) Metadata {
+	var m Metadata
+
+	open := strings.LastIndex(description, " (")
+	if open < 0 || !strings.HasSuffix(description, ")") {
+		return m
+	}
+
+	for _, part := range strings.Split(description[open+2:len(description)-1], ", ") {
+		switch {
+		case strings.HasPrefix(part, "run "):
+			m.PipelineRun = strings.TrimPrefix(part, "run ")
+		case strings.HasPrefix(part, "commit "):
+			m.CommitSHA = strings.TrimPrefix(part, "commit ")
+		case strings.HasPrefix(part, "by "):
+			m.Requester = strings.TrimPrefix(part, "by ")
+		default:
+			return Metadata{}
+		}
+	}
+
+	return m
+}
+
+// Init registers the metadata flags on the command. Flags which are not set
+// default to the STANDUP_* environment variables.
+func (m *Metadata) Init(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&m.CommitSHA, flagCommitSHA, os.Getenv(envCommitSHA), fmt.Sprintf(`The SHA of the commit under test. Defaults to $%s.`, envCommitSHA))
+	cmd.Flags().StringVar(&m.PipelineRun, flagPipelineRun, os.Getenv(envPipelineRun), fmt.Sprintf(`The ID of the pipeline run creating the resources. Defaults to $%s.`, envPipelineRun))
+	cmd.Flags().StringVar(&m.PullRequest, flagPullRequest, os.Getenv(envPullRequest), fmt.Sprintf(`The URL of the pull request under test. Defaults to $%s.`, envPullRequest))
+	cmd.Flags().StringVar(&m.Requester, flagRequester, os.Getenv(envRequester), fmt.Sprintf(`The person or team to contact about the created resources. Defaults to $%s.`, envRequester))
+}
+
+func (m Metadata) IsEmpty() bool {
+	return m == Metadata{}
+}
+
+// Annotations returns the full metadata for use as annotations.
+func (m Metadata) Annotations() map[string]string {
+	annotations := map[string]string{}
+	set(annotations, AnnotationCommitSHA, m.CommitSHA)
+	set(annotations, AnnotationPipelineRun, m.PipelineRun)
+	set(annotations, AnnotationPullRequest, m.PullRequest)
+	set(annotations, AnnotationRequester, m.Requester)
+
+	return annotations
+}
+
+// Labels returns the metadata which can be selected on, sanitized to be
+// valid label values. The pull request URL is only kept as an annotation.
+func (m Metadata) Labels() map[string]string {
+	labels := map[string]string{}
+	set(labels, LabelCommitSHA, labelValue(m.CommitSHA))
+	set(labels, LabelPipelineRun, labelValue(m.PipelineRun))
+	set(labels, LabelRequester, labelValue(m.Requester))
+
+	return labels
+}
+
+// Describe appends the metadata to the given cluster name, so it shows up
+// wherever clusters are listed.
+func (m Metadata) Describe(name string) string {
+	var parts []string
+	if m.PipelineRun != "" {
+		parts = append(parts, "run "+m.PipelineRun)
+	}
+	if m.CommitSHA != "" {
+		sha := m.CommitSHA
+		if len(sha) > 7 {
+			sha = sha[:7]
+		}
+		parts = append(parts, "commit "+sha)
+	}
+	if m.Requester != "" {
+		parts = append(parts, "by "+m.Requester)
+	}
+	if len(parts) == 0 {
+		return name
+	}
+
+	description := fmt.Sprintf("%s (%s)", name, strings.Join(parts, ", "))
+	if len(description) > maxNameLength {
+		description = description[:maxNameLength]
+	}
+
+	return description
+}
+
+// ObjectMeta adds the labels and annotations to the given maps, creating
+// them if necessary, and returns them.
+func (m Metadata) ObjectMeta(labels, annotations map[string]string) (map[string]string, map[string]string) {
+	if m.IsEmpty() {
+		return labels, annotations
+	}
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	for k, v := range m.Labels() {
+		labels[k] = v
+	}
+	for k, v := range m.Annotations() {
+		annotations[k] = v
+	}
+
+	return labels, annotations
+}
+
+func labelValue(value string) string {
+	value = invalidLabelValueCharacters.ReplaceAllString(value, "-")
+	if len(value) > 63 {
+		value = value[:63]
+	}
+
+	return strings.Trim(value, "-_.")
+}
+
+func set(m map[string]string, key, value string) {
+	if value != "" {
+		m[key] = value
+	}
+}
@@ -0,0 +1,129 @@
+package pipelinerun
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_Metadata_Labels(t *testing.T) {
+	testCases := []struct {
+		name     string
+		metadata Metadata
+		expected map[string]string
+	}{
+		{
+			name:     "case 0: empty metadata",
+			metadata: Metadata{},
+			expected: map[string]string{},
+		},
+		{
+			name: "case 1: values are sanitized",
+			metadata: Metadata{
+				CommitSHA:   "0123456789abcdef0123456789abcdef01234567",
+				PipelineRun: "aws-release-test-" + strings.Repeat("x", 60),
+				PullRequest: "https://github.com/giantswarm/releases/pull/1234",
+				Requester:   "Jane Doe <jane@example.com>",
+			},
+			expected: map[string]string{
+				LabelCommitSHA:   "0123456789abcdef0123456789abcdef01234567",
+				LabelPipelineRun: "aws-release-test-" + strings.Repeat("x", 46),
+				LabelRequester:   "Jane-Doe-jane-example.com",
+			},
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			result := tc.metadata.Labels()
+
+			if !cmp.Equal(result, tc.expected) {
+				t.Fatalf("\n\n%s\n", cmp.Diff(tc.expected, result))
+			}
+		})
+	}
+}
+
+func Test_Metadata_Describe(t *testing.T) {
+	testCases := []struct {
+		name     string
+		metadata Metadata
+		expected string
+	}{
+		{
+			name:     "case 0: empty metadata keeps the name",
+			metadata: Metadata{},
+			expected: "13.0.0-1616161616",
+		},
+		{
+			name: "case 1: run, commit and requester",
+			metadata: Metadata{
+				CommitSHA:   "0123456789abcdef0123456789abcdef01234567",
+				PipelineRun: "aws-release-test-abcde",
+				Requester:   "jane",
+			},
+			expected: "13.0.0-1616161616 (run aws-release-test-abcde, commit 0123456, by jane)",
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			result := tc.metadata.Describe("13.0.0-1616161616")
+
+			if result != tc.expected {
+				t.Fatalf("\n\n%s\n", cmp.Diff(tc.expected, result))
+			}
+		})
+	}
+}
+
+func Test_ParseDescription(t *testing.T) {
+	testCases := []struct {
+		name        string
+		description string
+		expected    Metadata
+	}{
+		{
+			name:        "case 0: name without metadata",
+			description: "13.0.0-1616161616",
+			expected:    Metadata{},
+		},
+		{
+			name:        "case 1: run, commit and requester",
+			description: "13.0.0-1616161616 (run aws-release-test-abcde, commit 0123456, by jane)",
+			expected: Metadata{
+				CommitSHA:   "0123456",
+				PipelineRun: "aws-release-test-abcde",
+				Requester:   "jane",
+			},
+		},
+		{
+			name:        "case 2: truncated description",
+			description: "13.0.0-1616161616 (run aws-release-test-abcde, commit 0123456, by ja",
+			expected:    Metadata{},
+		},
+		{
+			name:        "case 3: parentheses which are not metadata",
+			description: "my cluster (staging)",
+			expected:    Metadata{},
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			result := ParseDescription(tc.description)
+
+			if result != tc.expected {
+				t.Fatalf("\n\n%s\n", cmp.Diff(tc.expected, result))
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package probe
+
+import (
+	"regexp"
+
+	"github.com/giantswarm/microerror"
+)
+
+var apiNotAvailableError = &microerror.Error{
+	Kind: "apiNotAvailableError",
+}
+
+// IsAPINotAvailable asserts apiNotAvailableError.
+func IsAPINotAvailable(err error) bool {
+	return microerror.Cause(err) == apiNotAvailableError
+}
+
+var notReadyError = &microerror.Error{
+	Kind: "notReadyError",
+}
+
+// IsNotReady asserts notReadyError.
+func IsNotReady(err error) bool {
+	return microerror.Cause(err) == notReadyError
+}
+
+var releaseNotFoundError = &microerror.Error{
+	Kind: "releaseNotFoundError",
+}
+
+// IsReleaseNotFound asserts releaseNotFoundError.
+func IsReleaseNotFound(err error) bool {
+	return microerror.Cause(err) == releaseNotFoundError
+}
+
+var serverErrorPattern = regexp.MustCompile(`an error on the server .*`)
+
+// IsServerError asserts errors the API server returns while it is not
+// available yet.
+func IsServerError(err error) bool {
+	if err == nil {
+		return false
+	}
+	return serverErrorPattern.MatchString(err.Error())
+}
@@ -0,0 +1,226 @@
+// Package probe implements the readiness checks of a test cluster and its
+// release. Every probe checks once and returns notReadyError describing what
+// is missing, so `wait` can retry them and `status` can report them.
+package probe
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/giantswarm/apiextensions/v2/pkg/clientset/versioned"
+	applicationv1alpha1 "github.com/giantswarm/apiextensions/v3/pkg/apis/application/v1alpha1"
+	"github.com/giantswarm/errors/tenant"
+	"github.com/giantswarm/microerror"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ChartStatusDeployed is the release status of a successfully deployed chart.
+const ChartStatusDeployed = "deployed"
+
+// minCharts is the number of Chart CRs a cluster has at least once chart-operator is running.
+const minCharts = 2
+
+// Chart is the deployment status of a Chart CR in the workload cluster.
+type Chart struct {
+	Name     string `json:"name"`
+	SpecName string `json:"specName"`
+	Status   string `json:"status"`
+}
+
+// API checks that the API of the cluster is reachable. It returns
+// apiNotAvailableError while the API is still coming up.
+func API(ctx context.Context, k8sClient kubernetes.Interface) error {
+	_, err := k8sClient.CoreV1().Nodes().List(ctx, v1.ListOptions{})
+	if tenant.IsAPINotAvailable(err) || IsServerError(err) {
+		return microerror.Maskf(apiNotAvailableError, "%s", err)
+	} else if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// Nodes checks that at least the desired number of nodes is registered and
+// all registered nodes are ready.
+func Nodes(ctx context.Context, k8sClient kubernetes.Interface, desired int) error {
+	nodes, err := k8sClient.CoreV1().Nodes().List(ctx, v1.ListOptions{})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	nodeCount := len(nodes.Items)
+	readyCount := 0
+	for _, node := range nodes.Items {
+		for _, condition := range node.Status.Conditions {
+			if condition.Type == "Ready" {
+				if condition.Status == "True" {
+					readyCount++
+				}
+				break
+			}
+		}
+	}
+	if nodeCount < desired {
+		return microerror.Maskf(notReadyError, "found %d registered nodes, waiting for at least %d", nodeCount, desired)
+	}
+	if readyCount < nodeCount {
+		return microerror.Maskf(notReadyError, "%d out of %d nodes ready", readyCount, nodeCount)
+	}
+
+	return nil
+}
+
+// CoreDNS checks that all containers of the CoreDNS pods are ready.
+func CoreDNS(ctx context.Context, k8sClient kubernetes.Interface) error {
+	// Legacy GS clusters.
+	targetLabels := map[string]string{
+		"kubernetes.io/cluster-service": "true",
+		"kubernetes.io/name":            "CoreDNS",
+	}
+
+	// CAPI clusters.
+	alternateTargetLabels := map[string]string{
+		"kubernetes.io/cluster-service": "true",
+		"kubernetes.io/name":            "KubeDNS",
+	}
+
+	err := servicePodsReady(ctx, k8sClient, "CoreDNS", targetLabels, alternateTargetLabels)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// ExternalDNS checks that all containers of the external-dns pods are ready.
+func ExternalDNS(ctx context.Context, k8sClient kubernetes.Interface) error {
+	targetLabels := map[string]string{
+		"giantswarm.io/service-type": "managed",
+		"app.kubernetes.io/name":     "external-dns",
+	}
+
+	err := servicePodsReady(ctx, k8sClient, "external-dns", targetLabels)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// Charts checks that all Chart CRs in the giantswarm namespace are deployed.
+// The charts found are returned also when they are not ready.
+func Charts(ctx context.Context, ctrlClient client.Client) ([]Chart, error) {
+	charts := applicationv1alpha1.ChartList{}
+	err := ctrlClient.List(ctx, &charts, client.InNamespace("giantswarm"))
+	if err != nil {
+		return nil, microerror.Maskf(notReadyError, "cannot list charts in namespace giantswarm: %s", err)
+	}
+
+	result := make([]Chart, 0, len(charts.Items))
+	notDeployed := make([]string, 0)
+	for _, chart := range charts.Items {
+		result = append(result, Chart{
+			Name:     chart.Name,
+			SpecName: chart.Spec.Name,
+			Status:   chart.Status.Release.Status,
+		})
+		if chart.Status.Release.Status != ChartStatusDeployed {
+			notDeployed = append(notDeployed, chart.Name)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+
+	if len(result) < minCharts {
+		return result, microerror.Maskf(notReadyError, "waiting for at least %d Chart CRs to exist in giantswarm namespace, found %d", minCharts, len(result))
+	}
+	if len(notDeployed) > 0 {
+		return result, microerror.Maskf(notReadyError, "%d charts are not deployed yet: %v", len(notDeployed), notDeployed)
+	}
+
+	return result, nil
+}
+
+// Release checks that the Release CR of the given name is ready.
+func Release(ctx context.Context, g8sClient versioned.Interface, name string) error {
+	release, err := g8sClient.ReleaseV1alpha1().Releases().Get(ctx, name, v1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return microerror.Maskf(releaseNotFoundError, "release %#q", name)
+	} else if err != nil {
+		return microerror.Mask(err)
+	}
+	if !release.Status.Ready {
+		return microerror.Maskf(notReadyError, "release %#q is not ready yet", name)
+	}
+
+	return nil
+}
+
+// servicePodsReady checks that the pods selected by the first service found
+// with one of the given label sets are ready.
+func servicePodsReady(ctx context.Context, k8sClient kubernetes.Interface, name string, labelSets ...map[string]string) error {
+	var selectors []string
+	var podSelector map[string]string
+	for _, labels := range labelSets {
+		selector := labelsToSelector(labels)
+		selectors = append(selectors, selector)
+
+		list, err := k8sClient.CoreV1().Services("kube-system").List(ctx, v1.ListOptions{
+			LabelSelector: selector,
+		})
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		if len(list.Items) > 0 {
+			podSelector = list.Items[0].Spec.Selector
+			break
+		}
+	}
+	if podSelector == nil {
+		return microerror.Maskf(notReadyError, "%s service not found using label selectors %#q", name, selectors)
+	}
+
+	podLabelSelector := labelsToSelector(podSelector)
+	pods, err := k8sClient.CoreV1().Pods("kube-system").List(ctx, v1.ListOptions{
+		LabelSelector: podLabelSelector,
+	})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	if len(pods.Items) == 0 {
+		return microerror.Maskf(notReadyError, "%s pods not found using label selector %#q", name, podLabelSelector)
+	}
+
+	for _, pod := range pods.Items {
+		for _, container := range pod.Status.ContainerStatuses {
+			if !container.Ready {
+				return microerror.Maskf(notReadyError, "%s pod container %#q not ready", name, container.Name)
+			}
+		}
+	}
+
+	return nil
+}
+
+func labelsToSelector(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	selector := ""
+	for i, k := range keys {
+		if i > 0 {
+			selector += ","
+		}
+		selector += fmt.Sprintf("%s=%s", k, labels[k])
+	}
+
+	return selector
+}
@@ -0,0 +1,140 @@
+package probe
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func Test_Nodes(t *testing.T) {
+	testCases := []struct {
+		name         string
+		nodes        []runtime.Object
+		desired      int
+		errorMatcher func(error) bool
+	}{
+		{
+			name:    "case 0: all desired nodes ready",
+			nodes:   []runtime.Object{newNode("a", corev1.ConditionTrue), newNode("b", corev1.ConditionTrue)},
+			desired: 2,
+		},
+		{
+			name:         "case 1: too few nodes registered",
+			nodes:        []runtime.Object{newNode("a", corev1.ConditionTrue)},
+			desired:      2,
+			errorMatcher: IsNotReady,
+		},
+		{
+			name:         "case 2: registered node not ready",
+			nodes:        []runtime.Object{newNode("a", corev1.ConditionTrue), newNode("b", corev1.ConditionFalse)},
+			desired:      2,
+			errorMatcher: IsNotReady,
+		},
+		{
+			name:  "case 3: any number of ready nodes without desired count",
+			nodes: []runtime.Object{newNode("a", corev1.ConditionTrue)},
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			err := Nodes(context.Background(), fake.NewSimpleClientset(tc.nodes...), tc.desired)
+
+			switch {
+			case err == nil && tc.errorMatcher == nil:
+				// correct; carry on
+			case err != nil && tc.errorMatcher == nil:
+				t.Fatalf("error == %#v, want nil", err)
+			case err == nil && tc.errorMatcher != nil:
+				t.Fatalf("error == nil, want non-nil")
+			case !tc.errorMatcher(err):
+				t.Fatalf("error == %#v, want matching", err)
+			}
+		})
+	}
+}
+
+func Test_CoreDNS(t *testing.T) {
+	testCases := []struct {
+		name         string
+		objects      []runtime.Object
+		errorMatcher func(error) bool
+	}{
+		{
+			name:         "case 0: no service",
+			errorMatcher: IsNotReady,
+		},
+		{
+			name: "case 1: ready pods of the CAPI service",
+			objects: []runtime.Object{
+				newService("kube-dns", map[string]string{"kubernetes.io/cluster-service": "true", "kubernetes.io/name": "KubeDNS"}),
+				newPod("coredns-1", true),
+			},
+		},
+		{
+			name: "case 2: pod container not ready",
+			objects: []runtime.Object{
+				newService("coredns", map[string]string{"kubernetes.io/cluster-service": "true", "kubernetes.io/name": "CoreDNS"}),
+				newPod("coredns-1", false),
+			},
+			errorMatcher: IsNotReady,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			err := CoreDNS(context.Background(), fake.NewSimpleClientset(tc.objects...))
+
+			switch {
+			case err == nil && tc.errorMatcher == nil:
+				// correct; carry on
+			case err != nil && tc.errorMatcher == nil:
+				t.Fatalf("error == %#v, want nil", err)
+			case err == nil && tc.errorMatcher != nil:
+				t.Fatalf("error == nil, want non-nil")
+			case !tc.errorMatcher(err):
+				t.Fatalf("error == %#v, want matching", err)
+			}
+		})
+	}
+}
+
+func newNode(name string, ready corev1.ConditionStatus) *corev1.Node {
+	return &corev1.Node{
+		ObjectMeta: v1.ObjectMeta{Name: name},
+		Status: corev1.NodeStatus{
+			Conditions: []corev1.NodeCondition{
+				{Type: corev1.NodeReady, Status: ready},
+			},
+		},
+	}
+}
+
+func newService(name string, labels map[string]string) *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: v1.ObjectMeta{Name: name, Namespace: "kube-system", Labels: labels},
+		Spec: corev1.ServiceSpec{
+			Selector: map[string]string{"k8s-app": "coredns"},
+		},
+	}
+}
+
+func newPod(name string, ready bool) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: v1.ObjectMeta{Name: name, Namespace: "kube-system", Labels: map[string]string{"k8s-app": "coredns"}},
+		Status: corev1.PodStatus{
+			ContainerStatuses: []corev1.ContainerStatus{
+				{Name: "coredns", Ready: ready},
+			},
+		},
+	}
+}
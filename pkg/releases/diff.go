@@ -0,0 +1,278 @@
+package releases
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/giantswarm/apiextensions/v2/pkg/apis/release/v1alpha1"
+	"github.com/giantswarm/microerror"
+)
+
+// Kinds of the entries of a release.
+const (
+	KindApp       = "app"
+	KindComponent = "component"
+)
+
+// Changes of an entry between two releases. Changed entries keep their
+// version but differ in catalog, reference or component version.
+const (
+	ChangeAdded      = "added"
+	ChangeChanged    = "changed"
+	ChangeDowngraded = "downgraded"
+	ChangeRemoved    = "removed"
+	ChangeUpgraded   = "upgraded"
+)
+
+// Diff holds the changed apps and components between two releases.
+type Diff struct {
+	From    string   `json:"from"`
+	To      string   `json:"to"`
+	Changes []Change `json:"changes"`
+}
+
+type Change struct {
+	Kind   string `json:"kind"`
+	Name   string `json:"name"`
+	Change string `json:"change"`
+	// From is empty for added entries.
+	From *Entry `json:"from,omitempty"`
+	// To is empty for removed entries.
+	To *Entry `json:"to,omitempty"`
+}
+
+// Entry is an app or component of a release.
+type Entry struct {
+	Version string `json:"version"`
+	// ComponentVersion is the version of the upstream project an app deploys.
+	ComponentVersion string `json:"componentVersion,omitempty"`
+	Catalog          string `json:"catalog,omitempty"`
+	Reference        string `json:"reference,omitempty"`
+}
+
+// Compare returns the apps and components which were added, removed or
+// changed from one release to the other, sorted by kind and name.
+func Compare(from, to *v1alpha1.Release) Diff {
+	diff := Diff{
+		From:    from.Name,
+		To:      to.Name,
+		Changes: []Change{},
+	}
+
+	diff.Changes = append(diff.Changes, compare(KindApp, apps(from), apps(to))...)
+	diff.Changes = append(diff.Changes, compare(KindComponent, components(from), components(to))...)
+
+	return diff
+}
+
+// CompareToPrevious compares the given release of the provider to the
+// previous release in the releases repo, which is the greatest version less
+// than the given one. Like Versions, it returns the names of the skipped
+// directories.
+func CompareToPrevious(releasesPath, provider string, release *v1alpha1.Release) (Diff, []string, error) {
+	version, err := semver.NewVersion(strings.TrimPrefix(release.Name, "v"))
+	if err != nil {
+		return Diff{}, nil, microerror.Mask(err)
+	}
+
+	versions, skipped, err := Versions(releasesPath, provider)
+	if err != nil {
+		return Diff{}, nil, microerror.Mask(err)
+	}
+	previousVersion, err := Previous(versions, version)
+	if err != nil {
+		return Diff{}, skipped, microerror.Mask(err)
+	}
+	previous, err := Load(releasesPath, provider, previousVersion)
+	if err != nil {
+		return Diff{}, skipped, microerror.Mask(err)
+	}
+
+	return Compare(previous, release), skipped, nil
+}
+
+// Downgrades returns the downgraded apps and components.
+func (d Diff) Downgrades() []Change {
+	var downgrades []Change
+	for _, c := range d.Changes {
+		if c.Change == ChangeDowngraded {
+			downgrades = append(downgrades, c)
+		}
+	}
+
+	return downgrades
+}
+
+// WriteJSON writes the diff as indented JSON.
+func (d Diff) WriteJSON(w io.Writer) error {
+	data, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	_, err = fmt.Fprintf(w, "%s\n", data)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// WriteMarkdown writes the diff as a Markdown table for reviewers, followed by
+// a warning for each downgrade.
+func (d Diff) WriteMarkdown(w io.Writer) error {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "## Changes from %s to %s\n\n", d.From, d.To)
+	if len(d.Changes) == 0 {
+		fmt.Fprintf(&b, "No apps or components changed.\n")
+	} else {
+		fmt.Fprintf(&b, "| Kind | Name | Change | From | To | Catalog | Reference |\n")
+		fmt.Fprintf(&b, "|------|------|--------|------|----|---------|-----------|\n")
+		for _, c := range d.Changes {
+			fmt.Fprintf(&b, "| %s | %s | %s | %s | %s | %s | %s |\n",
+				c.Kind, c.Name, c.Change, formatVersion(c.From), formatVersion(c.To),
+				changed(c, func(e *Entry) string { return e.Catalog }),
+				changed(c, func(e *Entry) string { return e.Reference }),
+			)
+		}
+	}
+
+	for _, c := range d.Downgrades() {
+		fmt.Fprintf(&b, "\n> **Warning:** %s %s is downgraded from %s to %s.\n", c.Kind, c.Name, c.From.Version, c.To.Version)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+func compare(kind string, from, to map[string]Entry) []Change {
+	var changes []Change
+	for name, t := range to {
+		t := t
+		f, ok := from[name]
+		if !ok {
+			changes = append(changes, Change{Kind: kind, Name: name, Change: ChangeAdded, To: &t})
+			continue
+		}
+
+		change := compareVersions(f.Version, t.Version)
+		if change == "" && f != t {
+			change = ChangeChanged
+		}
+		if change != "" {
+			f := f
+			changes = append(changes, Change{Kind: kind, Name: name, Change: change, From: &f, To: &t})
+		}
+	}
+	for name, f := range from {
+		f := f
+		if _, ok := to[name]; !ok {
+			changes = append(changes, Change{Kind: kind, Name: name, Change: ChangeRemoved, From: &f})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Name < changes[j].Name
+	})
+
+	return changes
+}
+
+// compareVersions returns whether the version was upgraded or downgraded, or
+// an empty string if it is unchanged. Versions which are not semantic are only
+// compared for equality.
+func compareVersions(from, to string) string {
+	if from == to {
+		return ""
+	}
+
+	f, fErr := semver.NewVersion(from)
+	t, tErr := semver.NewVersion(to)
+	switch {
+	case fErr != nil || tErr != nil:
+		return ChangeChanged
+	case t.GreaterThan(f):
+		return ChangeUpgraded
+	case t.LessThan(f):
+		return ChangeDowngraded
+	default:
+		// Equal versions written differently, e.g. with a leading v.
+		return ""
+	}
+}
+
+func apps(release *v1alpha1.Release) map[string]Entry {
+	entries := map[string]Entry{}
+	for _, a := range release.Spec.Apps {
+		entries[a.Name] = Entry{
+			Version:          a.Version,
+			ComponentVersion: a.ComponentVersion,
+		}
+	}
+
+	return entries
+}
+
+func components(release *v1alpha1.Release) map[string]Entry {
+	entries := map[string]Entry{}
+	for _, c := range release.Spec.Components {
+		entries[c.Name] = Entry{
+			Version:   c.Version,
+			Catalog:   c.Catalog,
+			Reference: c.Reference,
+		}
+	}
+
+	return entries
+}
+
+// formatVersion formats the version of an entry, with the component version of
+// apps in parentheses.
+func formatVersion(e *Entry) string {
+	switch {
+	case e == nil:
+		return "-"
+	case e.ComponentVersion != "":
+		return fmt.Sprintf("%s (%s)", e.Version, e.ComponentVersion)
+	default:
+		return e.Version
+	}
+}
+
+// changed formats a field of the entries of a change, showing both values if
+// it changed.
+func changed(c Change, field func(e *Entry) string) string {
+	var from, to string
+	if c.From != nil {
+		from = field(c.From)
+	}
+	if c.To != nil {
+		to = field(c.To)
+	}
+
+	switch {
+	case c.From == nil:
+		return orNone(to)
+	case c.To == nil || from == to:
+		return orNone(from)
+	default:
+		return fmt.Sprintf("%s → %s", orNone(from), orNone(to))
+	}
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "-"
+	}
+
+	return s
+}
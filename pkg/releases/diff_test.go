@@ -0,0 +1,65 @@
+package releases
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/giantswarm/apiextensions/v2/pkg/apis/release/v1alpha1"
+	"github.com/google/go-cmp/cmp"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_Compare(t *testing.T) {
+	from := &v1alpha1.Release{
+		ObjectMeta: v1.ObjectMeta{Name: "v12.7.0"},
+		Spec: v1alpha1.ReleaseSpec{
+			Apps: []v1alpha1.ReleaseSpecApp{
+				{Name: "coredns", Version: "1.2.0", ComponentVersion: "1.6.5"},
+				{Name: "kiam", Version: "1.5.0"},
+			},
+			Components: []v1alpha1.ReleaseSpecComponent{
+				{Name: "aws-operator", Version: "9.1.0", Catalog: "control-plane-catalog"},
+				{Name: "cluster-operator", Version: "3.3.0"},
+				{Name: "kubernetes", Version: "1.18.9"},
+			},
+		},
+	}
+	to := &v1alpha1.Release{
+		ObjectMeta: v1.ObjectMeta{Name: "v13.0.0"},
+		Spec: v1alpha1.ReleaseSpec{
+			Apps: []v1alpha1.ReleaseSpecApp{
+				{Name: "cert-exporter", Version: "1.3.0"},
+				{Name: "coredns", Version: "1.2.0", ComponentVersion: "1.7.0"},
+			},
+			Components: []v1alpha1.ReleaseSpecComponent{
+				{Name: "aws-operator", Version: "9.0.0", Catalog: "control-plane-test-catalog", Reference: "9.0.0-abc123"},
+				{Name: "cluster-operator", Version: "3.3.0"},
+				{Name: "kubernetes", Version: "1.19.3"},
+			},
+		},
+	}
+
+	diff := Compare(from, to)
+
+	var b bytes.Buffer
+	err := diff.WriteMarkdown(&b)
+	if err != nil {
+		t.Fatalf("error == %#v, want nil", err)
+	}
+
+	expected := `## Changes from v12.7.0 to v13.0.0
+
+| Kind | Name | Change | From | To | Catalog | Reference |
+|------|------|--------|------|----|---------|-----------|
+| app | cert-exporter | added | - | 1.3.0 | - | - |
+| app | coredns | changed | 1.2.0 (1.6.5) | 1.2.0 (1.7.0) | - | - |
+| app | kiam | removed | 1.5.0 | - | - | - |
+| component | aws-operator | downgraded | 9.1.0 | 9.0.0 | control-plane-catalog → control-plane-test-catalog | - → 9.0.0-abc123 |
+| component | kubernetes | upgraded | 1.18.9 | 1.19.3 | - | - |
+
+> **Warning:** component aws-operator is downgraded from 9.1.0 to 9.0.0.
+`
+	if b.String() != expected {
+		t.Fatalf("\n\n%s\n", cmp.Diff(expected, b.String()))
+	}
+}
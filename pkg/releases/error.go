@@ -0,0 +1,21 @@
+package releases
+
+import "github.com/giantswarm/microerror"
+
+var releaseNotFoundError = &microerror.Error{
+	Kind: "releaseNotFoundError",
+}
+
+// IsReleaseNotFound asserts releaseNotFoundError.
+func IsReleaseNotFound(err error) bool {
+	return microerror.Cause(err) == releaseNotFoundError
+}
+
+var invalidRequestsError = &microerror.Error{
+	Kind: "invalidRequestsError",
+}
+
+// IsInvalidRequests asserts invalidRequestsError.
+func IsInvalidRequests(err error) bool {
+	return microerror.Cause(err) == invalidRequestsError
+}
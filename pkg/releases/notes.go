@@ -0,0 +1,248 @@
+package releases
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/giantswarm/apiextensions/v2/pkg/apis/release/v1alpha1"
+	"github.com/giantswarm/microerror"
+)
+
+// Notes are the changes of a release compared to the previous one, with the
+// requests of requests.yaml each change fulfils.
+type Notes struct {
+	Release  string       `json:"release"`
+	Previous string       `json:"previous"`
+	Changes  []NoteChange `json:"changes"`
+	// Unfulfilled are the requests for the release which its apps and
+	// components do not meet.
+	Unfulfilled []UnfulfilledRequest `json:"unfulfilled"`
+}
+
+type NoteChange struct {
+	Change
+	Requests []Request `json:"requests,omitempty"`
+}
+
+type UnfulfilledRequest struct {
+	Request
+	// Found is the version in the release, empty if it lacks the app or component.
+	Found string `json:"found,omitempty"`
+}
+
+// NewNotes merges the diff of the given release with the requests for its
+// version. A request is attributed to a change only if the change fulfils it,
+// i.e. the previous version did not satisfy it, as requests for older release
+// ranges keep matching later releases. Requests which are met without a change
+// are omitted.
+func NewNotes(diff Diff, release *v1alpha1.Release, reqs Requests) (Notes, error) {
+	version, err := semver.NewVersion(strings.TrimPrefix(release.Name, "v"))
+	if err != nil {
+		return Notes{}, microerror.Mask(err)
+	}
+	matching, err := reqs.For(version)
+	if err != nil {
+		return Notes{}, microerror.Mask(err)
+	}
+
+	notes := Notes{
+		Release:     diff.To,
+		Previous:    diff.From,
+		Changes:     []NoteChange{},
+		Unfulfilled: []UnfulfilledRequest{},
+	}
+
+	// Requests name apps and components alike, components take precedence
+	// like when releases are updated from requests.
+	entries := apps(release)
+	for name, e := range components(release) {
+		entries[name] = e
+	}
+
+	type fulfilled struct {
+		request    Request
+		constraint *semver.Constraints
+	}
+	requested := map[string][]fulfilled{}
+	for _, req := range matching {
+		constraint, err := semver.NewConstraint(req.Version)
+		if err != nil {
+			return Notes{}, microerror.Maskf(invalidRequestsError, "request %#q for %s: %s", req.Version, req.Name, err)
+		}
+
+		e, ok := entries[req.Name]
+		if !ok {
+			notes.Unfulfilled = append(notes.Unfulfilled, UnfulfilledRequest{Request: req})
+			continue
+		}
+		v, err := semver.NewVersion(e.Version)
+		if err != nil || !constraint.Check(v) {
+			notes.Unfulfilled = append(notes.Unfulfilled, UnfulfilledRequest{Request: req, Found: e.Version})
+			continue
+		}
+
+		requested[req.Name] = append(requested[req.Name], fulfilled{request: req, constraint: constraint})
+	}
+
+	for _, c := range diff.Changes {
+		n := NoteChange{Change: c}
+		if c.Change != ChangeRemoved {
+			for _, f := range requested[c.Name] {
+				if !satisfies(c.From, f.constraint) {
+					n.Requests = append(n.Requests, f.request)
+				}
+			}
+		}
+		notes.Changes = append(notes.Changes, n)
+	}
+
+	return notes, nil
+}
+
+// satisfies returns whether the entry exists and its version satisfies the
+// constraint.
+func satisfies(e *Entry, constraint *semver.Constraints) bool {
+	if e == nil {
+		return false
+	}
+	v, err := semver.NewVersion(e.Version)
+	if err != nil {
+		return false
+	}
+
+	return constraint.Check(v)
+}
+
+// WriteJSON writes the notes as indented JSON.
+func (n Notes) WriteJSON(w io.Writer) error {
+	data, err := json.MarshalIndent(n, "", "  ")
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	_, err = fmt.Fprintf(w, "%s\n", data)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// WriteMarkdown writes the notes as a changelog section for the release PR,
+// grouping changes into added, changed and removed apps and components.
+func (n Notes) WriteMarkdown(w io.Writer) error {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "## %s\n\n", n.Release)
+	fmt.Fprintf(&b, "Compared to %s.\n", n.Previous)
+
+	sections := []struct {
+		title   string
+		changes []string
+	}{
+		{title: "Added", changes: []string{ChangeAdded}},
+		{title: "Changed", changes: []string{ChangeUpgraded, ChangeDowngraded, ChangeChanged}},
+		{title: "Removed", changes: []string{ChangeRemoved}},
+	}
+	for _, s := range sections {
+		var lines []string
+		for _, c := range n.Changes {
+			for _, change := range s.changes {
+				if c.Change.Change == change {
+					lines = append(lines, noteLine(c))
+				}
+			}
+		}
+		if len(lines) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(&b, "\n### %s\n\n", s.title)
+		for _, l := range lines {
+			fmt.Fprintf(&b, "- %s\n", l)
+		}
+	}
+
+	if len(n.Unfulfilled) > 0 {
+		fmt.Fprintf(&b, "\n### Unfulfilled requests\n\n")
+		for _, u := range n.Unfulfilled {
+			got := "missing"
+			if u.Found != "" {
+				got = u.Found
+			}
+			fmt.Fprintf(&b, "- %s `%s` is %s%s.\n", u.Name, u.Version, got, issues([]Request{u.Request}))
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+func noteLine(c NoteChange) string {
+	var line string
+	switch c.Change.Change {
+	case ChangeAdded:
+		line = fmt.Sprintf("Add %s %s %s", c.Name, c.Kind, formatVersion(c.To))
+	case ChangeRemoved:
+		line = fmt.Sprintf("Remove %s %s %s", c.Name, c.Kind, formatVersion(c.From))
+	case ChangeUpgraded:
+		line = fmt.Sprintf("Upgrade %s %s from %s to %s", c.Name, c.Kind, formatVersion(c.From), formatVersion(c.To))
+	case ChangeDowngraded:
+		line = fmt.Sprintf("**Downgrade** %s %s from %s to %s", c.Name, c.Kind, formatVersion(c.From), formatVersion(c.To))
+	default:
+		line = fmt.Sprintf("Update %s %s %s", c.Name, c.Kind, formatVersion(c.To))
+	}
+
+	for _, field := range []struct {
+		name  string
+		value func(e *Entry) string
+	}{
+		{name: "catalog", value: func(e *Entry) string { return e.Catalog }},
+		{name: "reference", value: func(e *Entry) string { return e.Reference }},
+	} {
+		if c.To != nil && field.value(c.To) != "" && (c.From == nil || field.value(c.From) != field.value(c.To)) {
+			line += fmt.Sprintf(", %s `%s`", field.name, field.value(c.To))
+		}
+	}
+
+	return line + issues(c.Requests) + "."
+}
+
+// issues formats the issues of the given requests as Markdown links.
+func issues(reqs []Request) string {
+	var links []string
+	for _, r := range reqs {
+		if r.Issue != "" {
+			links = append(links, issueLink(r.Issue))
+		}
+	}
+	if len(links) == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf(" (requested in %s)", strings.Join(links, ", "))
+}
+
+// issueLink links a GitHub issue URL as owner/repo#number, other issues as
+// they are.
+func issueLink(issue string) string {
+	u, err := url.Parse(issue)
+	if err != nil || u.Host == "" {
+		return issue
+	}
+
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if u.Host == "github.com" && len(parts) == 4 && (parts[2] == "issues" || parts[2] == "pull") {
+		return fmt.Sprintf("[%s/%s#%s](%s)", parts[0], parts[1], parts[3], issue)
+	}
+
+	return fmt.Sprintf("[%s](%s)", issue, issue)
+}
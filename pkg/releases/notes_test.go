@@ -0,0 +1,91 @@
+package releases
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/giantswarm/apiextensions/v2/pkg/apis/release/v1alpha1"
+	"github.com/google/go-cmp/cmp"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_NewNotes(t *testing.T) {
+	from := &v1alpha1.Release{
+		ObjectMeta: v1.ObjectMeta{Name: "v12.7.0"},
+		Spec: v1alpha1.ReleaseSpec{
+			Apps: []v1alpha1.ReleaseSpecApp{
+				{Name: "kiam", Version: "1.5.0"},
+			},
+			Components: []v1alpha1.ReleaseSpecComponent{
+				{Name: "app-operator", Version: "2.3.0"},
+				{Name: "kubernetes", Version: "1.18.9"},
+				{Name: "cluster-operator", Version: "3.4.0"},
+			},
+		},
+	}
+	to := &v1alpha1.Release{
+		ObjectMeta: v1.ObjectMeta{Name: "v13.0.0"},
+		Spec: v1alpha1.ReleaseSpec{
+			Apps: []v1alpha1.ReleaseSpecApp{
+				{Name: "cert-exporter", Version: "1.3.0"},
+			},
+			Components: []v1alpha1.ReleaseSpecComponent{
+				{Name: "app-operator", Version: "2.3.0"},
+				{Name: "kubernetes", Version: "1.19.3", Reference: "1.19.3-gs1"},
+				{Name: "cluster-operator", Version: "3.5.0"},
+			},
+		},
+	}
+	reqs := Requests{
+		Releases: []RequestedRelease{
+			{
+				// Already satisfied by the previous release, so not attributed to the upgrade.
+				Name: ">= 12.0.0",
+				Requests: []Request{
+					{Name: "cluster-operator", Version: ">= 3.4.0", Issue: "#12"},
+				},
+			},
+			{
+				Name: ">= 13.0.0",
+				Requests: []Request{
+					{Name: "kubernetes", Version: ">= 1.19.0", Issue: "https://github.com/giantswarm/roadmap/issues/123"},
+					{Name: "cert-exporter", Version: ">= 1.2.0", Issue: "#45"},
+					{Name: "app-operator", Version: ">= 2.3.0"},
+					{Name: "app-operator", Version: ">= 3.0.0", Issue: "https://example.com/app-operator"},
+				},
+			},
+			{
+				Name: ">= 14.0.0",
+				Requests: []Request{
+					{Name: "kubernetes", Version: ">= 1.20.0"},
+				},
+			},
+		},
+	}
+
+	notes, err := NewNotes(Compare(from, to), to, reqs)
+	if err != nil {
+		t.Fatalf("error == %#v, want nil", err)
+	}
+
+	var b bytes.Buffer
+	err = notes.WriteMarkdown(&b)
+	if err != nil {
+		t.Fatalf("error == %#v, want nil", err)
+	}
+
+	expected := "## v13.0.0\n\n" +
+		"Compared to v12.7.0.\n\n" +
+		"### Added\n\n" +
+		"- Add cert-exporter app 1.3.0 (requested in #45).\n\n" +
+		"### Changed\n\n" +
+		"- Upgrade cluster-operator component from 3.4.0 to 3.5.0.\n" +
+		"- Upgrade kubernetes component from 1.18.9 to 1.19.3, reference `1.19.3-gs1` (requested in [giantswarm/roadmap#123](https://github.com/giantswarm/roadmap/issues/123)).\n\n" +
+		"### Removed\n\n" +
+		"- Remove kiam app 1.5.0.\n\n" +
+		"### Unfulfilled requests\n\n" +
+		"- app-operator `>= 3.0.0` is 2.3.0 (requested in [https://example.com/app-operator](https://example.com/app-operator)).\n"
+	if b.String() != expected {
+		t.Fatalf("\n\n%s\n", cmp.Diff(expected, b.String()))
+	}
+}
@@ -0,0 +1,94 @@
+// Package releases reads the releases of a provider from a local checkout of
+// the releases repo and compares them.
+package releases
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/giantswarm/apiextensions/v2/pkg/apis/release/v1alpha1"
+	"github.com/giantswarm/microerror"
+	"sigs.k8s.io/yaml"
+)
+
+// Versions returns the ascending versions of the releases of the given
+// provider in the releases repo. It also returns the names of the directories
+// which are skipped as they are not named after a semantic version, apart from
+// archived, so callers can report them.
+func Versions(releasesPath, provider string) ([]*semver.Version, []string, error) {
+	entries, err := os.ReadDir(filepath.Join(releasesPath, provider))
+	if err != nil {
+		return nil, nil, microerror.Mask(err)
+	}
+
+	var versions []*semver.Version
+	var skipped []string
+	for _, f := range entries {
+		if !f.IsDir() || f.Name() == "archived" {
+			continue
+		}
+
+		version, err := semver.NewVersion(strings.TrimPrefix(f.Name(), "v"))
+		if err != nil {
+			skipped = append(skipped, f.Name())
+			continue
+		}
+		versions = append(versions, version)
+	}
+
+	sort.Sort(semver.Collection(versions))
+
+	return versions, skipped, nil
+}
+
+// Latest returns the greatest of the given versions.
+func Latest(versions []*semver.Version) (*semver.Version, error) {
+	if len(versions) == 0 {
+		return nil, microerror.Maskf(releaseNotFoundError, "Can't find a valid release")
+	}
+
+	return versions[len(versions)-1], nil
+}
+
+// Previous returns the greatest of the given ascending versions which is less
+// than the given version.
+func Previous(versions []*semver.Version, version *semver.Version) (*semver.Version, error) {
+	var previous *semver.Version
+	for _, v := range versions {
+		if !v.LessThan(version) {
+			break
+		}
+		previous = v
+	}
+
+	if previous == nil {
+		return nil, microerror.Maskf(releaseNotFoundError, "no release before v%s", version)
+	}
+
+	return previous, nil
+}
+
+// Load reads the Release CR of the given version of the provider from the
+// releases repo.
+func Load(releasesPath, provider string, version *semver.Version) (*v1alpha1.Release, error) {
+	releasePath := filepath.Join(releasesPath, provider, fmt.Sprintf("v%s", version), "release.yaml")
+
+	releaseYAML, err := os.ReadFile(releasePath)
+	if os.IsNotExist(err) {
+		return nil, microerror.Maskf(releaseNotFoundError, "release %#q does not exist", releasePath)
+	} else if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	var release v1alpha1.Release
+	err = yaml.Unmarshal(releaseYAML, &release)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return &release, nil
+}
@@ -0,0 +1,85 @@
+package releases
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_Previous(t *testing.T) {
+	releasesPath := t.TempDir()
+	for _, dir := range []string{"v12.7.0", "v13.0.0", "v13.0.0-beta1", "v9.3.1", "archived", "kustomization"} {
+		err := os.MkdirAll(filepath.Join(releasesPath, "aws", dir), 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	testCases := []struct {
+		name         string
+		version      string
+		expected     string
+		errorMatcher func(error) bool
+	}{
+		{
+			name:     "case 0: previous release of a new release",
+			version:  "13.1.0",
+			expected: "13.0.0",
+		},
+		{
+			name:     "case 1: previous release of an existing release",
+			version:  "13.0.0",
+			expected: "13.0.0-beta1",
+		},
+		{
+			name:     "case 2: versions are compared semantically",
+			version:  "12.0.0",
+			expected: "9.3.1",
+		},
+		{
+			name:         "case 3: first release",
+			version:      "9.3.1",
+			errorMatcher: IsReleaseNotFound,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			versions, skipped, err := Versions(releasesPath, "aws")
+			if err != nil {
+				t.Fatalf("error == %#v, want nil", err)
+			}
+			expectedSkipped := []string{"kustomization"}
+			if !cmp.Equal(skipped, expectedSkipped) {
+				t.Fatalf("\n\n%s\n", cmp.Diff(expectedSkipped, skipped))
+			}
+
+			previous, err := Previous(versions, semver.MustParse(tc.version))
+
+			switch {
+			case err == nil && tc.errorMatcher == nil:
+				// correct; carry on
+			case err != nil && tc.errorMatcher == nil:
+				t.Fatalf("error == %#v, want nil", err)
+			case err == nil && tc.errorMatcher != nil:
+				t.Fatalf("error == nil, want non-nil")
+			case !tc.errorMatcher(err):
+				t.Fatalf("error == %#v, want matching", err)
+			}
+
+			var result string
+			if previous != nil {
+				result = previous.String()
+			}
+			if result != tc.expected {
+				t.Fatalf("\n\n%s\n", cmp.Diff(tc.expected, result))
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package releases
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/giantswarm/microerror"
+	"sigs.k8s.io/yaml"
+)
+
+// Requests are the minimum versions of apps and components requested for
+// future releases of a provider in its requests.yaml.
+type Requests struct {
+	Releases []RequestedRelease `json:"releases"`
+}
+
+// RequestedRelease holds the requests for the releases matching the version
+// constraint in Name, e.g. ">= 13.0.0".
+type RequestedRelease struct {
+	Name     string    `json:"name"`
+	Requests []Request `json:"requests"`
+}
+
+// Request asks for a version of an app or component, e.g. ">= 1.1.0", and
+// links the issue motivating it.
+type Request struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Issue   string `json:"issue,omitempty"`
+}
+
+// LoadRequests reads the requests.yaml of the given provider from the
+// releases repo.
+func LoadRequests(releasesPath, provider string) (Requests, error) {
+	var reqs Requests
+
+	data, err := os.ReadFile(filepath.Join(releasesPath, provider, "requests.yaml"))
+	if err != nil {
+		return Requests{}, microerror.Mask(err)
+	}
+
+	err = yaml.Unmarshal(data, &reqs)
+	if err != nil {
+		return Requests{}, microerror.Mask(err)
+	}
+
+	return reqs, nil
+}
+
+// For returns the requests of the releases whose constraint matches the given
+// version, in the order of requests.yaml.
+func (r Requests) For(version *semver.Version) ([]Request, error) {
+	var matching []Request
+	for _, release := range r.Releases {
+		constraint, err := semver.NewConstraint(release.Name)
+		if err != nil {
+			return nil, microerror.Maskf(invalidRequestsError, "release %#q: %s", release.Name, err)
+		}
+		if constraint.Check(version) {
+			matching = append(matching, release.Requests...)
+		}
+	}
+
+	return matching, nil
+}
@@ -0,0 +1,21 @@
+package rollback
+
+import "github.com/giantswarm/microerror"
+
+var invalidConfigError = &microerror.Error{
+	Kind: "invalidConfigError",
+}
+
+// IsInvalidConfig asserts invalidConfigError.
+func IsInvalidConfig(err error) bool {
+	return microerror.Cause(err) == invalidConfigError
+}
+
+var rollbackFailedError = &microerror.Error{
+	Kind: "rollbackFailedError",
+}
+
+// IsRollbackFailed asserts rollbackFailedError.
+func IsRollbackFailed(err error) bool {
+	return microerror.Cause(err) == rollbackFailedError
+}
@@ -0,0 +1,102 @@
+// Package rollback keeps track of the resources a command created, so they
+// can be removed again when the command is interrupted half way through.
+package rollback
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+)
+
+// Func removes a resource created earlier in the same invocation.
+type Func func(ctx context.Context) error
+
+type Config struct {
+	Logger micrologger.Logger
+
+	// GracePeriod is the time available to roll back once the command has
+	// been interrupted.
+	GracePeriod time.Duration
+}
+
+type Stack struct {
+	logger micrologger.Logger
+
+	gracePeriod time.Duration
+
+	mutex sync.Mutex
+	steps []step
+}
+
+type step struct {
+	description string
+	f           Func
+}
+
+func New(config Config) (*Stack, error) {
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+
+	s := &Stack{
+		logger: config.Logger,
+
+		gracePeriod: config.GracePeriod,
+	}
+
+	return s, nil
+}
+
+// Add registers the removal of a resource which has just been created.
+func (s *Stack) Add(description string, f Func) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.steps = append(s.steps, step{description: description, f: f})
+}
+
+// RunIfInterrupted rolls back all registered steps in reverse order if the
+// given context has been cancelled, e.g. by SIGTERM. The steps run with a new
+// context limited to the grace period. Failing steps don't stop the rollback.
+func (s *Stack) RunIfInterrupted(ctx context.Context) error {
+	if ctx.Err() == nil {
+		return nil
+	}
+
+	s.mutex.Lock()
+	steps := s.steps
+	s.steps = nil
+	s.mutex.Unlock()
+
+	if len(steps) == 0 || s.gracePeriod <= 0 {
+		return nil
+	}
+
+	rollbackCtx, cancel := context.WithTimeout(context.Background(), s.gracePeriod)
+	defer cancel()
+
+	s.logger.LogCtx(rollbackCtx, "level", "warning", "message", fmt.Sprintf("interrupted, rolling back %d step(s) within %s", len(steps), s.gracePeriod))
+
+	var failed int
+	for i := len(steps) - 1; i >= 0; i-- {
+		s.logger.LogCtx(rollbackCtx, "level", "warning", "message", fmt.Sprintf("rolling back: %s", steps[i].description))
+
+		err := steps[i].f(rollbackCtx)
+		if err != nil {
+			failed++
+			s.logger.LogCtx(rollbackCtx, "level", "error", "message", fmt.Sprintf("failed to roll back: %s", steps[i].description), "stack", microerror.JSON(err))
+		}
+	}
+
+	if failed > 0 {
+		return microerror.Maskf(rollbackFailedError, "%d of %d rollback steps failed", failed, len(steps))
+	}
+
+	s.logger.LogCtx(rollbackCtx, "level", "warning", "message", "rolled back")
+
+	return nil
+}
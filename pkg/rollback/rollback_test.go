@@ -0,0 +1,99 @@
+package rollback
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/giantswarm/micrologger"
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_RunIfInterrupted(t *testing.T) {
+	testCases := []struct {
+		name          string
+		interrupted   bool
+		failingSteps  map[string]bool
+		expectedSteps []string
+		errorMatcher  func(error) bool
+	}{
+		{
+			name:          "case 0: nothing is rolled back without an interrupt",
+			interrupted:   false,
+			expectedSteps: nil,
+			errorMatcher:  nil,
+		},
+		{
+			name:          "case 1: steps are rolled back in reverse order",
+			interrupted:   true,
+			expectedSteps: []string{"c", "b", "a"},
+			errorMatcher:  nil,
+		},
+		{
+			name:          "case 2: a failing step doesn't stop the rollback",
+			interrupted:   true,
+			failingSteps:  map[string]bool{"b": true},
+			expectedSteps: []string{"c", "b", "a"},
+			errorMatcher:  IsRollbackFailed,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			logger, err := micrologger.New(micrologger.Config{
+				IOWriter: io.Discard,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			s, err := New(Config{
+				Logger:      logger,
+				GracePeriod: time.Second,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var steps []string
+			for _, description := range []string{"a", "b", "c"} {
+				d := description
+				s.Add(d, func(ctx context.Context) error {
+					steps = append(steps, d)
+					if tc.failingSteps[d] {
+						return errors.New("failed")
+					}
+					return nil
+				})
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tc.interrupted {
+				cancel()
+			}
+
+			err = s.RunIfInterrupted(ctx)
+
+			switch {
+			case err == nil && tc.errorMatcher == nil:
+				// correct; carry on
+			case err != nil && tc.errorMatcher == nil:
+				t.Fatalf("error == %#v, want nil", err)
+			case err == nil && tc.errorMatcher != nil:
+				t.Fatalf("error == nil, want non-nil")
+			case !tc.errorMatcher(err):
+				t.Fatalf("error == %#v, want matching", err)
+			}
+
+			if !cmp.Equal(steps, tc.expectedSteps) {
+				t.Fatalf("\n\n%s\n", cmp.Diff(tc.expectedSteps, steps))
+			}
+		})
+	}
+}
@@ -0,0 +1,21 @@
+package semaphore
+
+import "github.com/giantswarm/microerror"
+
+var invalidConfigError = &microerror.Error{
+	Kind: "invalidConfigError",
+}
+
+// IsInvalidConfig asserts invalidConfigError.
+func IsInvalidConfig(err error) bool {
+	return microerror.Cause(err) == invalidConfigError
+}
+
+var noCapacityError = &microerror.Error{
+	Kind: "noCapacityError",
+}
+
+// IsNoCapacity asserts noCapacityError.
+func IsNoCapacity(err error) bool {
+	return microerror.Cause(err) == noCapacityError
+}
@@ -0,0 +1,263 @@
+// Package semaphore implements a distributed counting semaphore backed by a
+// ConfigMap in the management cluster. It limits how many test clusters
+// standup runs on an installation at the same time.
+package semaphore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/giantswarm/backoff"
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/giantswarm/standup/pkg/key"
+)
+
+const (
+	dataKey = "state"
+	// waiterTTLPolls is the number of polls a waiter may miss before it is
+	// dropped from the queue.
+	waiterTTLPolls = 3
+)
+
+type Config struct {
+	K8sClient kubernetes.Interface
+	Logger    micrologger.Logger
+
+	// Name and Namespace of the ConfigMap holding the semaphore state.
+	Name      string
+	Namespace string
+	// Slots is the number of holders allowed at the same time.
+	Slots int
+	// MaxSlotLifetime is the time after the last renewal at which a slot is
+	// reclaimed. Holders renew their slot with Heartbeat, but nothing renews
+	// a slot renamed to a cluster, so a cluster holds it at most this long.
+	MaxSlotLifetime time.Duration
+	// PollInterval is the time between two attempts to acquire a slot.
+	// Waiters which missed three polls are dropped from the queue.
+	PollInterval time.Duration
+}
+
+type Semaphore struct {
+	k8sClient kubernetes.Interface
+	logger    micrologger.Logger
+
+	name            string
+	namespace       string
+	slots           int
+	maxSlotLifetime time.Duration
+	pollInterval    time.Duration
+}
+
+func New(config Config) (*Semaphore, error) {
+	if config.K8sClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.K8sClient must not be empty", config)
+	}
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+	if config.Name == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Name must not be empty", config)
+	}
+	if config.Namespace == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Namespace must not be empty", config)
+	}
+	if config.Slots < 1 {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Slots must be at least 1", config)
+	}
+	if config.MaxSlotLifetime <= 0 {
+		return nil, microerror.Maskf(invalidConfigError, "%T.MaxSlotLifetime must be positive", config)
+	}
+	if config.PollInterval <= 0 {
+		config.PollInterval = 20 * time.Second
+	}
+
+	s := &Semaphore{
+		k8sClient: config.K8sClient,
+		logger:    config.Logger,
+
+		name:            config.Name,
+		namespace:       config.Namespace,
+		slots:           config.Slots,
+		maxSlotLifetime: config.MaxSlotLifetime,
+		pollInterval:    config.PollInterval,
+	}
+
+	return s, nil
+}
+
+// Acquire blocks until the holder got a slot. Waiters are served in the order
+// in which they started waiting.
+func (s *Semaphore) Acquire(ctx context.Context, holder string) error {
+	o := func() error {
+		var acquired bool
+		var position int
+		var inUse int
+		err := s.update(ctx, func(st *state) bool {
+			acquired, position = st.tryAcquire(holder, s.slots, time.Now())
+			inUse = len(st.Holders)
+			return true
+		})
+		if err != nil {
+			return backoff.Permanent(microerror.Mask(err))
+		}
+
+		if !acquired {
+			s.logger.LogCtx(ctx, "message", fmt.Sprintf("waiting for capacity: position %d in queue, %d of %d slots in use", position, inUse, s.slots))
+			return microerror.Mask(noCapacityError)
+		}
+
+		return nil
+	}
+	// Retry basically forever, the tekton task will determine maximum runtime.
+	b := key.WaitBackOff(ctx, 0, s.pollInterval)
+
+	err := backoff.Retry(o, b)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	s.logger.LogCtx(ctx, "message", fmt.Sprintf("acquired capacity slot for %#q", holder))
+
+	return nil
+}
+
+// Heartbeat renews the slot of the holder until the context is done. It is
+// meant to run in its own goroutine.
+func (s *Semaphore) Heartbeat(ctx context.Context, holder string) {
+	ticker := time.NewTicker(s.maxSlotLifetime / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := s.update(ctx, func(st *state) bool {
+				return st.renew(holder, time.Now())
+			})
+			if err != nil {
+				s.logger.LogCtx(ctx, "level", "warning", "message", fmt.Sprintf("failed to renew capacity slot for %#q", holder), "stack", microerror.JSON(err))
+			}
+		}
+	}
+}
+
+// Rename hands over the slot of a holder to a new name, e.g. the ID of the
+// cluster created with it, so it can be released later by that name. The
+// renamed slot is renewed once and reclaimed after MaxSlotLifetime unless
+// released before.
+func (s *Semaphore) Rename(ctx context.Context, from, to string) error {
+	err := s.update(ctx, func(st *state) bool {
+		return st.rename(from, to, time.Now())
+	})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// Release frees the slot of the holder. Releasing an unknown holder is not an
+// error, so releasing is idempotent.
+func (s *Semaphore) Release(ctx context.Context, holder string) error {
+	var released bool
+	err := s.update(ctx, func(st *state) bool {
+		released = st.release(holder)
+		return released
+	})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	if released {
+		s.logger.LogCtx(ctx, "message", fmt.Sprintf("released capacity slot for %#q", holder))
+	} else {
+		s.logger.LogCtx(ctx, "message", fmt.Sprintf("no capacity slot held by %#q", holder))
+	}
+
+	return nil
+}
+
+// update applies the mutation to the semaphore state and writes it back,
+// retrying on conflicting updates by other holders. Expired holders are
+// reclaimed on every update. The mutation returns whether it changed the state.
+func (s *Semaphore) update(ctx context.Context, mutate func(st *state) bool) error {
+	o := func() error {
+		cm, err := s.k8sClient.CoreV1().ConfigMaps(s.namespace).Get(ctx, s.name, v1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			cm = &corev1.ConfigMap{
+				ObjectMeta: v1.ObjectMeta{
+					Name:      s.name,
+					Namespace: s.namespace,
+				},
+			}
+			cm, err = s.k8sClient.CoreV1().ConfigMaps(s.namespace).Create(ctx, cm, v1.CreateOptions{})
+		}
+		if apierrors.IsAlreadyExists(err) {
+			return microerror.Mask(err)
+		} else if err != nil {
+			return backoff.Permanent(microerror.Mask(err))
+		}
+
+		st := state{
+			Holders: map[string]entry{},
+			Queue:   map[string]entry{},
+		}
+		if data := cm.Data[dataKey]; data != "" {
+			err = json.Unmarshal([]byte(data), &st)
+			if err != nil {
+				return backoff.Permanent(microerror.Mask(err))
+			}
+			if st.Holders == nil {
+				st.Holders = map[string]entry{}
+			}
+			if st.Queue == nil {
+				st.Queue = map[string]entry{}
+			}
+		}
+
+		expired := st.expire(time.Now(), s.maxSlotLifetime, waiterTTLPolls*s.pollInterval)
+		for _, name := range expired {
+			s.logger.LogCtx(ctx, "level", "warning", "message", fmt.Sprintf("reclaimed capacity slot of %#q after missed heartbeats", name))
+		}
+
+		changed := mutate(&st)
+		if !changed && len(expired) == 0 {
+			return nil
+		}
+
+		data, err := json.Marshal(st)
+		if err != nil {
+			return backoff.Permanent(microerror.Mask(err))
+		}
+		if cm.Data == nil {
+			cm.Data = map[string]string{}
+		}
+		cm.Data[dataKey] = string(data)
+
+		_, err = s.k8sClient.CoreV1().ConfigMaps(s.namespace).Update(ctx, cm, v1.UpdateOptions{})
+		if apierrors.IsConflict(err) {
+			return microerror.Mask(err)
+		} else if err != nil {
+			return backoff.Permanent(microerror.Mask(err))
+		}
+
+		return nil
+	}
+	b := backoff.NewMaxRetries(10, time.Second)
+
+	err := backoff.Retry(o, b)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
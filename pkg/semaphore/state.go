@@ -0,0 +1,133 @@
+package semaphore
+
+import (
+	"sort"
+	"time"
+)
+
+// entry is a holder of a slot or a waiter in the queue.
+type entry struct {
+	// Since is the time the holder acquired its slot or entered the queue.
+	Since time.Time `json:"since"`
+	// Renewed is the time of the last heartbeat.
+	Renewed time.Time `json:"renewed"`
+}
+
+// state is the content of the semaphore ConfigMap.
+type state struct {
+	Holders map[string]entry `json:"holders"`
+	Queue   map[string]entry `json:"queue"`
+}
+
+// expire removes holders whose last renewal is older than the maximum slot
+// lifetime and waiters whose last poll is older than the waiter TTL, and
+// returns the names of the removed holders. Waiters have a TTL of their own,
+// as a crashed waiter at the head of the queue blocks all later waiters.
+func (s *state) expire(now time.Time, maxSlotLifetime, waiterTTL time.Duration) []string {
+	var expired []string
+	for name, e := range s.Holders {
+		if now.Sub(e.Renewed) > maxSlotLifetime {
+			delete(s.Holders, name)
+			expired = append(expired, name)
+		}
+	}
+	for name, e := range s.Queue {
+		if now.Sub(e.Renewed) > waiterTTL {
+			delete(s.Queue, name)
+		}
+	}
+
+	sort.Strings(expired)
+
+	return expired
+}
+
+// queued returns the waiters ordered by the time they entered the queue.
+func (s *state) queued() []string {
+	var names []string
+	for name := range s.Queue {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		a, b := s.Queue[names[i]], s.Queue[names[j]]
+		if a.Since.Equal(b.Since) {
+			return names[i] < names[j]
+		}
+		return a.Since.Before(b.Since)
+	})
+
+	return names
+}
+
+// tryAcquire enqueues the holder if necessary and moves it to the holders if
+// a slot is free and no earlier waiter is entitled to it. It returns whether
+// the slot was acquired and otherwise the 1-based position in the queue.
+func (s *state) tryAcquire(holder string, slots int, now time.Time) (bool, int) {
+	if e, ok := s.Holders[holder]; ok {
+		e.Renewed = now
+		s.Holders[holder] = e
+		return true, 0
+	}
+
+	if e, ok := s.Queue[holder]; ok {
+		e.Renewed = now
+		s.Queue[holder] = e
+	} else {
+		s.Queue[holder] = entry{Since: now, Renewed: now}
+	}
+
+	free := slots - len(s.Holders)
+	for i, name := range s.queued() {
+		if name != holder {
+			continue
+		}
+		if i < free {
+			delete(s.Queue, holder)
+			s.Holders[holder] = entry{Since: now, Renewed: now}
+			return true, 0
+		}
+		return false, i + 1
+	}
+
+	return false, len(s.Queue)
+}
+
+// renew records a heartbeat for the holder or waiter and reports whether it
+// was found.
+func (s *state) renew(holder string, now time.Time) bool {
+	if e, ok := s.Holders[holder]; ok {
+		e.Renewed = now
+		s.Holders[holder] = e
+		return true
+	}
+	if e, ok := s.Queue[holder]; ok {
+		e.Renewed = now
+		s.Queue[holder] = e
+		return true
+	}
+
+	return false
+}
+
+// rename hands the slot or queue position of a holder over to a new name.
+func (s *state) rename(from, to string, now time.Time) bool {
+	if e, ok := s.Holders[from]; ok {
+		delete(s.Holders, from)
+		e.Renewed = now
+		s.Holders[to] = e
+		return true
+	}
+
+	return false
+}
+
+// release removes the holder from both the holders and the queue and
+// reports whether it was found.
+func (s *state) release(holder string) bool {
+	_, held := s.Holders[holder]
+	_, queued := s.Queue[holder]
+	delete(s.Holders, holder)
+	delete(s.Queue, holder)
+
+	return held || queued
+}
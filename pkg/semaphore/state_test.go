@@ -0,0 +1,139 @@
+package semaphore
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func Test_state_tryAcquire(t *testing.T) {
+	now := time.Date(2023, 3, 15, 12, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name             string
+		state            state
+		holder           string
+		slots            int
+		expectedAcquired bool
+		expectedPosition int
+		expectedHolders  []string
+	}{
+		{
+			name: "case 0: free slot and empty queue",
+			state: state{
+				Holders: map[string]entry{},
+				Queue:   map[string]entry{},
+			},
+			holder:           "abc12",
+			slots:            1,
+			expectedAcquired: true,
+			expectedHolders:  []string{"abc12"},
+		},
+		{
+			name: "case 1: all slots in use",
+			state: state{
+				Holders: map[string]entry{
+					"abc12": {Since: now, Renewed: now},
+				},
+				Queue: map[string]entry{
+					"def34": {Since: now.Add(-time.Minute), Renewed: now},
+				},
+			},
+			holder:           "ghi56",
+			slots:            1,
+			expectedAcquired: false,
+			expectedPosition: 2,
+			expectedHolders:  []string{"abc12"},
+		},
+		{
+			name: "case 2: free slot is reserved for earlier waiter",
+			state: state{
+				Holders: map[string]entry{},
+				Queue: map[string]entry{
+					"def34": {Since: now.Add(-time.Minute), Renewed: now},
+				},
+			},
+			holder:           "ghi56",
+			slots:            1,
+			expectedAcquired: false,
+			expectedPosition: 2,
+			expectedHolders:  []string{},
+		},
+		{
+			name: "case 3: first waiter gets the free slot",
+			state: state{
+				Holders: map[string]entry{
+					"abc12": {Since: now, Renewed: now},
+				},
+				Queue: map[string]entry{
+					"def34": {Since: now.Add(-time.Minute), Renewed: now},
+					"ghi56": {Since: now.Add(-2 * time.Minute), Renewed: now},
+				},
+			},
+			holder:           "ghi56",
+			slots:            2,
+			expectedAcquired: true,
+			expectedHolders:  []string{"abc12", "ghi56"},
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			acquired, position := tc.state.tryAcquire(tc.holder, tc.slots, now)
+			if acquired != tc.expectedAcquired {
+				t.Fatalf("expected acquired %t, got %t", tc.expectedAcquired, acquired)
+			}
+			if position != tc.expectedPosition {
+				t.Fatalf("expected position %d, got %d", tc.expectedPosition, position)
+			}
+
+			holders := []string{}
+			for name := range tc.state.Holders {
+				holders = append(holders, name)
+			}
+			if !cmp.Equal(holders, tc.expectedHolders, cmpopts.SortSlices(func(a, b string) bool { return a < b })) {
+				t.Fatalf("\n\n%s\n", cmp.Diff(tc.expectedHolders, holders))
+			}
+		})
+	}
+}
+
+func Test_state_expire(t *testing.T) {
+	now := time.Date(2023, 3, 15, 12, 0, 0, 0, time.UTC)
+
+	st := state{
+		Holders: map[string]entry{
+			"abc12": {Since: now.Add(-3 * time.Hour), Renewed: now.Add(-2 * time.Hour)},
+			"def34": {Since: now.Add(-3 * time.Hour), Renewed: now.Add(-time.Minute)},
+		},
+		Queue: map[string]entry{
+			"ghi56": {Since: now.Add(-3 * time.Hour), Renewed: now.Add(-2 * time.Hour)},
+			// Missed its polls, but would still be within the holder lease.
+			"jkl78": {Since: now.Add(-10 * time.Minute), Renewed: now.Add(-2 * time.Minute)},
+			"mno90": {Since: now.Add(-5 * time.Minute), Renewed: now.Add(-20 * time.Second)},
+		},
+	}
+
+	expired := st.expire(now, time.Hour, time.Minute)
+	if !cmp.Equal(expired, []string{"abc12"}) {
+		t.Fatalf("\n\n%s\n", cmp.Diff([]string{"abc12"}, expired))
+	}
+	if _, ok := st.Holders["def34"]; !ok {
+		t.Fatalf("expected holder def34 to be kept")
+	}
+	if !cmp.Equal(st.queued(), []string{"mno90"}) {
+		t.Fatalf("\n\n%s\n", cmp.Diff([]string{"mno90"}, st.queued()))
+	}
+
+	// The next waiter takes the free slot once the crashed waiter ahead of it
+	// has been dropped.
+	acquired, _ := st.tryAcquire("mno90", 2, now)
+	if !acquired {
+		t.Fatalf("expected waiter mno90 to acquire the slot of the expired waiter")
+	}
+}
@@ -9,20 +9,31 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
+
+	"github.com/giantswarm/standup/pkg/key"
 )
 
 const (
 	conformanceImage         = "k8s.gcr.io/conformance:corev1.18.6"
 	conformanceNamespaceName = "conformance"
 	conformanceResourceName  = "conformance"
+
+	defaultConformanceFocus = "\\[Conformance\\]"
 )
 
 type Config struct {
 	Config *rest.Config
+
+	// Conformance is the conformance configuration of the pipeline. Its focus and skip are passed
+	// to the conformance image as E2E_FOCUS and E2E_SKIP. The focus defaults to all conformance tests.
+	Conformance key.ConformanceConfig
 }
 
 type Test struct {
 	k8sClient kubernetes.Interface
+
+	conformanceFocus string
+	conformanceSkip  string
 }
 
 func New(config Config) (*Test, error) {
@@ -31,8 +42,15 @@ func New(config Config) (*Test, error) {
 		return nil, microerror.Mask(err)
 	}
 
+	if config.Conformance.Focus == "" {
+		config.Conformance.Focus = defaultConformanceFocus
+	}
+
 	test := Test{
 		k8sClient: k8sClient,
+
+		conformanceFocus: config.Conformance.Focus,
+		conformanceSkip:  config.Conformance.Skip,
 	}
 
 	return &test, nil
@@ -133,11 +151,11 @@ func (t *Test) RunKubernetesConformance(ctx context.Context) error {
 					Env: []corev1.EnvVar{
 						{
 							Name:  "E2E_FOCUS",
-							Value: "\\[Conformance\\]",
+							Value: t.conformanceFocus,
 						},
 						{
 							Name:  "E2E_SKIP",
-							Value: "",
+							Value: t.conformanceSkip,
 						},
 						{
 							Name:  "E2E_PROVIDER",
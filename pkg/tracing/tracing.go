@@ -0,0 +1,272 @@
+// Package tracing exports OpenTelemetry traces of standup commands. The trace
+// context is handed from one command to the next through a traceparent file
+// in the output directory or the TRACEPARENT environment variable, so all
+// commands of a pipeline run show up in a single trace.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	"github.com/spf13/cobra"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	flagEndpoint    = "otlp-endpoint"
+	flagTraceparent = "traceparent"
+
+	envEndpoint    = "OTEL_EXPORTER_OTLP_ENDPOINT"
+	envTraceparent = "TRACEPARENT"
+)
+
+const (
+	// TraceparentFile is the name of the file in the output directory which
+	// holds the trace context for the next command.
+	TraceparentFile = "traceparent"
+
+	serviceName     = "standup"
+	tracerName      = "github.com/giantswarm/standup"
+	tracesURLPath   = "/v1/traces"
+	traceparentKey  = "traceparent"
+	shutdownTimeout = 10 * time.Second
+)
+
+// spanProcessors are added to the tracer provider of every command.
+var spanProcessors []sdktrace.SpanProcessor
+
+// RegisterSpanProcessor adds a span processor to the tracer provider of the
+// commands run afterwards, e.g. to print steps as they start and end.
+func RegisterSpanProcessor(p sdktrace.SpanProcessor) {
+	spanProcessors = append(spanProcessors, p)
+}
+
+// Flags configures where traces are exported to and which trace the command
+// belongs to.
+type Flags struct {
+	Endpoint    string
+	Traceparent string
+}
+
+// Init registers the tracing flags on the command. Flags which are not set
+// default to the standard OpenTelemetry environment variables.
+func (f *Flags) Init(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&f.Endpoint, flagEndpoint, os.Getenv(envEndpoint), fmt.Sprintf(`The URL of an OTLP/HTTP endpoint to export traces to, e.g. http://localhost:4318. Defaults to $%s.`, envEndpoint))
+	cmd.Flags().StringVar(&f.Traceparent, flagTraceparent, os.Getenv(envTraceparent), fmt.Sprintf(`The W3C traceparent of the pipeline run, so the command joins its trace. Defaults to $%s, then to the traceparent file a previous command wrote to the output directory.`, envTraceparent))
+}
+
+// ResolveTraceparent returns the traceparent of the flag or environment, or
+// otherwise the one a previous command wrote to the given output directory.
+// Without either, the command starts a new trace.
+func (f *Flags) ResolveTraceparent(dir string) (string, error) {
+	if f.Traceparent != "" || dir == "" {
+		return f.Traceparent, nil
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, TraceparentFile))
+	if os.IsNotExist(err) {
+		return "", nil
+	} else if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	return strings.TrimSpace(string(data)), nil
+}
+
+type Config struct {
+	Logger micrologger.Logger
+
+	// Command is the name of the span covering the whole command.
+	Command string
+	// Endpoint is the URL of the OTLP/HTTP endpoint. Empty disables export,
+	// the trace context is still propagated and spans still processed by
+	// registered span processors.
+	Endpoint string
+	// Traceparent is the W3C trace context of the parent span, if any.
+	Traceparent string
+}
+
+type Tracing struct {
+	logger micrologger.Logger
+
+	provider *sdktrace.TracerProvider
+	span     trace.Span
+}
+
+// New sets up the export of spans and starts the span of the command. The
+// returned context carries the command span and is used for all steps.
+func New(ctx context.Context, config Config) (*Tracing, context.Context, error) {
+	if config.Logger == nil {
+		return nil, nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+	if config.Command == "" {
+		return nil, nil, microerror.Maskf(invalidConfigError, "%T.Command must not be empty", config)
+	}
+
+	providerOptions := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(resource.NewWithAttributes(
+			semconv.SchemaURL,
+			semconv.ServiceNameKey.String(serviceName),
+		)),
+	}
+	if config.Endpoint != "" {
+		options, err := exporterOptions(config.Endpoint)
+		if err != nil {
+			return nil, nil, microerror.Mask(err)
+		}
+
+		exporter, err := otlptracehttp.New(ctx, options...)
+		if err != nil {
+			return nil, nil, microerror.Mask(err)
+		}
+
+		providerOptions = append(providerOptions, sdktrace.WithBatcher(exporter))
+	}
+	for _, p := range spanProcessors {
+		providerOptions = append(providerOptions, sdktrace.WithSpanProcessor(p))
+	}
+
+	// Without anything to process spans, the default no-op provider only
+	// propagates the trace context.
+	var provider *sdktrace.TracerProvider
+	if config.Endpoint != "" || len(spanProcessors) > 0 {
+		provider = sdktrace.NewTracerProvider(providerOptions...)
+		otel.SetTracerProvider(provider)
+	}
+
+	if config.Traceparent != "" {
+		ctx = propagation.TraceContext{}.Extract(ctx, propagation.MapCarrier{traceparentKey: config.Traceparent})
+		if !trace.SpanContextFromContext(ctx).IsValid() {
+			return nil, nil, microerror.Maskf(invalidConfigError, "%T.Traceparent %#q is not a valid W3C traceparent", config, config.Traceparent)
+		}
+	}
+
+	ctx, span := otel.Tracer(tracerName).Start(ctx, config.Command)
+
+	t := &Tracing{
+		logger: config.Logger,
+
+		provider: provider,
+		span:     span,
+	}
+
+	return t, ctx, nil
+}
+
+// Traceparent returns the W3C trace context of the command span, to be used
+// as parent by the next command of the pipeline run.
+func (t *Tracing) Traceparent() string {
+	carrier := propagation.MapCarrier{}
+	propagation.TraceContext{}.Inject(trace.ContextWithSpan(context.Background(), t.span), carrier)
+
+	return carrier.Get(traceparentKey)
+}
+
+// WriteTraceparent writes the trace context of the command span to the
+// traceparent file in the given directory.
+func (t *Tracing) WriteTraceparent(ctx context.Context, dir string) error {
+	traceparent := t.Traceparent()
+	if traceparent == "" {
+		return nil
+	}
+
+	path := filepath.Join(dir, TraceparentFile)
+	t.logger.LogCtx(ctx, "message", fmt.Sprintf("writing traceparent to path %s", path))
+	err := os.WriteFile(path, []byte(traceparent), 0644) //#nosec
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// End ends the command span with the outcome of the command and exports all
+// remaining spans. It is meant to be called once when the command ends.
+func (t *Tracing) End(err error) error {
+	end(t.span, err)
+
+	if t.provider == nil {
+		return nil
+	}
+
+	// The command context may already be cancelled by an interrupt.
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	err = t.provider.Shutdown(ctx)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// Start starts a span for a lifecycle step. The returned function ends it
+// with the outcome of the step.
+func Start(ctx context.Context, step string) (context.Context, func(err error)) {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, step)
+
+	return ctx, func(err error) {
+		end(span, err)
+	}
+}
+
+// Notify returns a backoff notifier which records failed attempts as events
+// on the span of the given context.
+func Notify(ctx context.Context) func(err error, delay time.Duration) {
+	span := trace.SpanFromContext(ctx)
+
+	return func(err error, delay time.Duration) {
+		span.AddEvent("retry", trace.WithAttributes(
+			attribute.String("error", err.Error()),
+			attribute.String("delay", delay.String()),
+		))
+	}
+}
+
+func end(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	} else {
+		span.SetStatus(codes.Ok, "")
+	}
+	span.End()
+}
+
+// exporterOptions converts the endpoint URL into options of the OTLP/HTTP
+// exporter, which expects host and path separately.
+func exporterOptions(endpoint string) ([]otlptracehttp.Option, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, microerror.Maskf(invalidConfigError, "endpoint %#q is not a valid URL: %s", endpoint, err)
+	}
+	if u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, microerror.Maskf(invalidConfigError, "endpoint %#q must be an http or https URL", endpoint)
+	}
+
+	options := []otlptracehttp.Option{
+		otlptracehttp.WithEndpoint(u.Host),
+		otlptracehttp.WithURLPath(strings.TrimSuffix(u.Path, "/") + tracesURLPath),
+	}
+	if u.Scheme == "http" {
+		options = append(options, otlptracehttp.WithInsecure())
+	}
+
+	return options, nil
+}
@@ -0,0 +1,175 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/giantswarm/micrologger"
+)
+
+func Test_Tracing(t *testing.T) {
+	testCases := []struct {
+		name                string
+		traceparent         string
+		export              bool
+		expectedTraceID     string
+		expectedExportPaths []string
+		errorMatcher        func(error) bool
+	}{
+		{
+			name:            "case 0: the command joins the trace of the traceparent",
+			traceparent:     "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			expectedTraceID: "4bf92f3577b34da6a3ce929d0e0e4736",
+			errorMatcher:    nil,
+		},
+		{
+			name:                "case 1: spans are exported to the endpoint when the command ends",
+			traceparent:         "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			export:              true,
+			expectedTraceID:     "4bf92f3577b34da6a3ce929d0e0e4736",
+			expectedExportPaths: []string{"/v1/traces"},
+			errorMatcher:        nil,
+		},
+		{
+			name:         "case 2: an invalid traceparent is rejected",
+			traceparent:  "not-a-traceparent",
+			errorMatcher: IsInvalidConfig,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			var mutex sync.Mutex
+			var exportPaths []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				mutex.Lock()
+				exportPaths = append(exportPaths, req.URL.Path)
+				mutex.Unlock()
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer server.Close()
+
+			logger, err := micrologger.New(micrologger.Config{
+				IOWriter: io.Discard,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			c := Config{
+				Logger: logger,
+
+				Command:     "standup test",
+				Traceparent: tc.traceparent,
+			}
+			if tc.export {
+				c.Endpoint = server.URL
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			tracing, ctx, err := New(ctx, c)
+
+			switch {
+			case err == nil && tc.errorMatcher == nil:
+				// correct; carry on
+			case err != nil && tc.errorMatcher == nil:
+				t.Fatalf("error == %#v, want nil", err)
+			case err == nil && tc.errorMatcher != nil:
+				t.Fatalf("error == nil, want non-nil")
+			case !tc.errorMatcher(err):
+				t.Fatalf("error == %#v, want matching", err)
+			}
+			if err != nil {
+				return
+			}
+
+			traceparent := tracing.Traceparent()
+			if !strings.Contains(traceparent, tc.expectedTraceID) {
+				t.Fatalf("traceparent == %#q, want trace ID %#q", traceparent, tc.expectedTraceID)
+			}
+
+			stepCtx, end := Start(ctx, "step")
+			Notify(stepCtx)(errors.New("not ready"), time.Second)
+			end(nil)
+
+			err = tracing.End(nil)
+			if err != nil {
+				t.Fatalf("error == %#v, want nil", err)
+			}
+
+			mutex.Lock()
+			defer mutex.Unlock()
+			if strings.Join(exportPaths, ",") != strings.Join(tc.expectedExportPaths, ",") {
+				t.Fatalf("exported to %v, want %v", exportPaths, tc.expectedExportPaths)
+			}
+		})
+	}
+}
+
+func Test_ResolveTraceparent(t *testing.T) {
+	written := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	explicit := "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"
+
+	outputDir := t.TempDir()
+	err := os.WriteFile(filepath.Join(outputDir, TraceparentFile), []byte(written+"\n"), 0644) //#nosec
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name        string
+		traceparent string
+		dir         string
+		expected    string
+	}{
+		{
+			name:     "case 0: the traceparent file of the previous command is read",
+			dir:      outputDir,
+			expected: written,
+		},
+		{
+			name:        "case 1: the flag or environment takes precedence over the file",
+			traceparent: explicit,
+			dir:         outputDir,
+			expected:    explicit,
+		},
+		{
+			name:     "case 2: without a traceparent file a new trace is started",
+			dir:      t.TempDir(),
+			expected: "",
+		},
+		{
+			name:     "case 3: without an output directory a new trace is started",
+			expected: "",
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Log(tc.name)
+
+			f := Flags{Traceparent: tc.traceparent}
+			result, err := f.ResolveTraceparent(tc.dir)
+			if err != nil {
+				t.Fatalf("error == %#v, want nil", err)
+			}
+			if result != tc.expected {
+				t.Fatalf("traceparent == %#q, want %#q", result, tc.expected)
+			}
+		})
+	}
+}
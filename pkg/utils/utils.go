@@ -1,18 +1,25 @@
 package utils
 
-// map of features (we're depending on standup) by provider
-var providerFeatureSet = map[string][]string{
-	"kvm":       {},
-	"openstack": {},
-	"azure":     {"external-dns"},
-	"gcp":       {"external-dns"},
-	"aws":       {"external-dns"},
+const (
+	// FeatureExternalDNS is provided by clusters running external-dns, which
+	// has to be ready before DNS dependent tests can start.
+	FeatureExternalDNS = "external-dns"
+)
+
+// map of the features we're depending on in standup to the names of the apps
+// or charts providing them
+var featureApps = map[string][]string{
+	FeatureExternalDNS: {"external-dns", "external-dns-app"},
 }
 
-func ProviderHasFeature(provider, feature string) bool {
-	for _, n := range providerFeatureSet[provider] {
-		if feature == n {
-			return true
+// AppsProvideFeature reports whether any of the given app or chart names, as
+// found in Chart CRs or a release's app list, provides the feature.
+func AppsProvideFeature(appNames []string, feature string) bool {
+	for _, n := range appNames {
+		for _, a := range featureApps[feature] {
+			if n == a {
+				return true
+			}
 		}
 	}
 	return false